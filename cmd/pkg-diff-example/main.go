@@ -10,10 +10,23 @@ import (
 	"os"
 
 	"github.com/pkg/diff"
+	"github.com/pkg/diff/histogram"
+	"github.com/pkg/diff/myers"
+	"github.com/pkg/diff/patience"
 	"github.com/pkg/diff/write"
 )
 
-var color = flag.Bool("color", false, "colorize the output")
+var (
+	color     = flag.Bool("color", false, "colorize the output")
+	algorithm = flag.String("algorithm", "myers", "diff `algorithm` to use: myers, patience, or histogram")
+	unified   = flag.Int("U", 3, "output `n` lines of context")
+)
+
+var algorithms = map[string]diff.Algorithm{
+	"myers":     myers.Diff,
+	"patience":  patience.Diff,
+	"histogram": histogram.Diff,
+}
 
 // check logs a fatal error and exits if err is not nil.
 func check(err error) {
@@ -60,7 +73,15 @@ func main() {
 		flag.Usage()
 	}
 
-	var opts []write.Option
+	algo, ok := algorithms[*algorithm]
+	if !ok {
+		log.Fatalf("unknown algorithm %q", *algorithm)
+	}
+	if *unified < 0 {
+		log.Fatalf("invalid context length %d", *unified)
+	}
+
+	opts := []write.Option{diff.WithAlgorithm(algo), diff.ContextLines(*unified)}
 	if *color {
 		opts = append(opts, write.TerminalColor())
 	}
@@ -3,7 +3,6 @@
 // It is implemented in terms of the other packages in this module.
 // If you want fine-grained control,
 // want to inspect a diff programmatically,
-// want to provide a context for cancellation,
 // need to diff gigantic files that don't fit in memory,
 // or want to diff unusual things,
 // use the lower level packages.
@@ -20,14 +19,28 @@ import (
 	"strings"
 
 	"github.com/pkg/diff/ctxt"
+	"github.com/pkg/diff/funcname"
 	"github.com/pkg/diff/intern"
 	"github.com/pkg/diff/myers"
 	"github.com/pkg/diff/write"
 )
 
+// textLines holds the lines of one side of a Text diff.
+type textLines struct {
+	// lines are the lines, without their trailing newlines.
+	// Any other line terminator, such as the '\r' of "\r\n", is part of the line.
+	lines []*string
+	// keys are the lines as they are compared.
+	// Unless line terminators are ignored, they are the same as lines.
+	keys []*string
+	// noNewline reports whether the final line lacks a trailing newline.
+	noNewline bool
+}
+
 // lines returns the lines contained in text/filename.
 // text and filename are interpreted as described in the docs for Text.
-func lines(m intern.Strings, filename string, text interface{}) ([]*string, error) {
+// If ignoreCR is set, a '\r' at the end of a line is not part of its key.
+func lines(m intern.Strings, filename string, text interface{}, ignoreCR bool) (*textLines, error) {
 	var r io.Reader
 	switch text := text.(type) {
 	case nil:
@@ -46,10 +59,29 @@ func lines(m intern.Strings, filename string, text interface{}) ([]*string, erro
 	default:
 		return nil, fmt.Errorf("unexpected type %T, want string, []byte, io.Reader, or nil", text)
 	}
-	var x []*string
+	x := new(textLines)
 	scan := bufio.NewScanner(r)
+	scan.Split(func(data []byte, atEOF bool) (advance int, token []byte, err error) {
+		if i := bytes.IndexByte(data, '\n'); i >= 0 {
+			x.noNewline = false
+			return i + 1, data[:i], nil
+		}
+		if atEOF && len(data) > 0 {
+			x.noNewline = true
+			return len(data), data, nil
+		}
+		// Request more data.
+		return 0, nil, nil
+	})
 	for scan.Scan() {
-		x = append(x, m.FromBytes(scan.Bytes()))
+		line := scan.Bytes()
+		x.lines = append(x.lines, m.FromBytes(line))
+		if ignoreCR {
+			x.keys = append(x.keys, m.FromBytes(bytes.TrimSuffix(line, []byte{'\r'})))
+		}
+	}
+	if !ignoreCR {
+		x.keys = x.lines
 	}
 	return x, scan.Err()
 }
@@ -65,54 +97,135 @@ func addNames(aName, bName string, options []write.Option) []write.Option {
 }
 
 // Text diffs a and b and writes the result to w.
-// It treats a and b as text, and splits their contents
-// into lines using bufio.ScanLines.
+// It treats a and b as text, and splits their contents into lines at each newline.
+// Other line terminators, such as the carriage return of a "\r\n",
+// are considered part of the line: they are compared,
+// and they are written to w unchanged.
+// To compare lines without a trailing carriage return, use IgnoreCRAtEOL.
 // aFile and bFile are filenames to use in the output.
 //
 // a and b each may be nil or may have type string, []byte, or io.Reader.
 // If nil, the text is read from the filename.
+//
+// In addition to write options, options may include
+// WithAlgorithm, ContextLines, IgnoreCRAtEOL, and FuncNames.
 func Text(aFile, bFile string, a, b interface{}, w io.Writer, options ...write.Option) error {
+	return TextContext(context.Background(), aFile, bFile, a, b, w, options...)
+}
+
+// TextContext is like Text, but it passes ctx to the diff algorithm.
+// If ctx is cancelled before the diff is complete,
+// TextContext writes nothing and returns ctx.Err().
+func TextContext(ctx context.Context, aFile, bFile string, a, b interface{}, w io.Writer, options ...write.Option) error {
+	c := newConfig(options)
 	m := make(intern.Strings)
-	aLines, err := lines(m, aFile, a)
+	aLines, err := lines(m, aFile, a, c.ignoreCR)
+	if err != nil {
+		return err
+	}
+	bLines, err := lines(m, bFile, b, c.ignoreCR)
 	if err != nil {
 		return err
 	}
-	bLines, err := lines(m, bFile, b)
+	ab := newDiffStrings(aLines, bLines)
+	s, err := c.diff(ctx, ab)
 	if err != nil {
 		return err
 	}
-	ab := &diffStrings{a: aLines, b: bLines}
-	s := myers.Diff(context.Background(), ab)
-	s = ctxt.Size(s, 3)
-	opts := addNames(aFile, bFile, options)
+	s = ctxt.Size(s, c.context)
+	opts := addNames(aFile, bFile, c.write)
+	if c.funcs != nil {
+		a := make([]string, len(aLines.lines))
+		for i, line := range aLines.lines {
+			a[i] = *line
+		}
+		opts = append(opts, write.SectionHeaders(funcname.Headers(a, c.funcs)))
+	}
 	err = write.Unified(s, w, ab, opts...)
 	return err
 }
 
+// A diffStrings diffs two texts line by line.
+// A final line that lacks a trailing newline
+// is not equal to one that has a trailing newline.
 type diffStrings struct {
-	a, b []*string
+	a, b   []*string // lines, as written
+	ka, kb []*string // lines, as compared
+
+	// aNoNewline and bNoNewline report whether
+	// the last line of a and b lacks a trailing newline.
+	aNoNewline, bNoNewline bool
+}
+
+func newDiffStrings(a, b *textLines) *diffStrings {
+	return &diffStrings{
+		a: a.lines, b: b.lines,
+		ka: a.keys, kb: b.keys,
+		aNoNewline: a.noNewline, bNoNewline: b.noNewline,
+	}
 }
 
 func (ab *diffStrings) LenA() int                                { return len(ab.a) }
 func (ab *diffStrings) LenB() int                                { return len(ab.b) }
-func (ab *diffStrings) Equal(ai, bi int) bool                    { return ab.a[ai] == ab.b[bi] }
+func (ab *diffStrings) NoNewlineA(ai int) bool                   { return ab.aNoNewline && ai == len(ab.a)-1 }
+func (ab *diffStrings) NoNewlineB(bi int) bool                   { return ab.bNoNewline && bi == len(ab.b)-1 }
 func (ab *diffStrings) WriteATo(w io.Writer, i int) (int, error) { return io.WriteString(w, *ab.a[i]) }
 func (ab *diffStrings) WriteBTo(w io.Writer, i int) (int, error) { return io.WriteString(w, *ab.b[i]) }
 
+func (ab *diffStrings) Equal(ai, bi int) bool {
+	return ab.ka[ai] == ab.kb[bi] && ab.NoNewlineA(ai) == ab.NoNewlineB(bi)
+}
+
+// A noNewline is the key of a line that lacks a trailing newline.
+type noNewline struct {
+	s *string
+}
+
+func (ab *diffStrings) KeyA(ai int) interface{} {
+	if ab.NoNewlineA(ai) {
+		return noNewline{ab.ka[ai]}
+	}
+	return ab.ka[ai]
+}
+
+func (ab *diffStrings) KeyB(bi int) interface{} {
+	if ab.NoNewlineB(bi) {
+		return noNewline{ab.kb[bi]}
+	}
+	return ab.kb[bi]
+}
+
 // Slices diffs slices a and b and writes the result to w.
 // It uses fmt.Print to print the elements of a and b.
 // It uses reflect.DeepEqual to compare elements of a and b.
 // It uses aName and bName as the names of a and b in the output.
+//
+// In addition to write options, options may include
+// WithAlgorithm and ContextLines.
 func Slices(aName, bName string, a, b interface{}, w io.Writer, options ...write.Option) error {
+	return SlicesContext(context.Background(), aName, bName, a, b, w, options...)
+}
+
+// SlicesContext is like Slices, but it passes ctx to the diff algorithm.
+// If ctx is cancelled before the diff is complete,
+// SlicesContext writes nothing and returns ctx.Err().
+func SlicesContext(ctx context.Context, aName, bName string, a, b interface{}, w io.Writer, options ...write.Option) error {
+	c := newConfig(options)
 	ab := &diffSlices{a: reflect.ValueOf(a), b: reflect.ValueOf(b)}
 	if err := ab.validateTypes(); err != nil {
 		return err
 	}
-	s := myers.Diff(context.Background(), ab)
-	s = ctxt.Size(s, 3)
-	opts := addNames(aName, bName, options)
-	err := write.Unified(s, w, ab, opts...)
-	return err
+	var p myers.Pair = ab
+	if ab.keyable() {
+		p = keyedSlices{ab}
+	}
+	s, err := c.diff(ctx, p)
+	if err != nil {
+		return err
+	}
+	s = ctxt.Size(s, c.context)
+	opts := addNames(aName, bName, c.write)
+	return write.Unified(s, w, ab, opts...)
 }
 
 type diffSlices struct {
@@ -136,3 +249,30 @@ func (ab *diffSlices) validateTypes() error {
 	}
 	return nil
 }
+
+// keyable reports whether the elements of a and b have basic types,
+// such as string or int, which can be compared using ==
+// with the same result as reflect.DeepEqual.
+func (ab *diffSlices) keyable() bool {
+	return isBasic(ab.a.Type().Elem()) && isBasic(ab.b.Type().Elem())
+}
+
+func isBasic(t reflect.Type) bool {
+	switch t.Kind() {
+	case reflect.Bool, reflect.String,
+		reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr,
+		reflect.Float32, reflect.Float64, reflect.Complex64, reflect.Complex128:
+		return true
+	}
+	return false
+}
+
+// A keyedSlices is a diffSlices whose elements can serve as map keys,
+// as used by the patience and histogram packages.
+type keyedSlices struct {
+	*diffSlices
+}
+
+func (ab keyedSlices) KeyA(ai int) interface{} { return ab.atA(ai) }
+func (ab keyedSlices) KeyB(bi int) interface{} { return ab.atB(bi) }
@@ -0,0 +1,127 @@
+package diff_test
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/pkg/diff"
+	"github.com/pkg/diff/write"
+)
+
+func TestContextCancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	buf := new(bytes.Buffer)
+	err := diff.TextContext(ctx, "a", "b", "a\nb\nc\n", "a\nc\nd\n", buf)
+	if err != context.Canceled {
+		t.Errorf("TextContext returned %v, want %v", err, context.Canceled)
+	}
+	if buf.Len() != 0 {
+		t.Errorf("TextContext wrote %q after cancellation, want nothing", buf)
+	}
+
+	buf.Reset()
+	err = diff.SlicesContext(ctx, "a", "b", []int{1, 2, 3}, []int{1, 3, 4}, buf)
+	if err != context.Canceled {
+		t.Errorf("SlicesContext returned %v, want %v", err, context.Canceled)
+	}
+	if buf.Len() != 0 {
+		t.Errorf("SlicesContext wrote %q after cancellation, want nothing", buf)
+	}
+}
+
+func TestNoNewline(t *testing.T) {
+	tests := []struct {
+		name string
+		a, b string
+		want string // from diff -u
+	}{
+		{
+			name: "Removed",
+			a:    "a\nb\n",
+			b:    "a\nb",
+			want: `
+--- a
++++ b
+@@ -1,2 +1,2 @@
+ a
+-b
++b
+\ No newline at end of file
+`[1:],
+		},
+		{
+			name: "Added",
+			a:    "a\nb",
+			b:    "a\nb\n",
+			want: `
+--- a
++++ b
+@@ -1,2 +1,2 @@
+ a
+-b
+\ No newline at end of file
++b
+`[1:],
+		},
+		{
+			name: "Unchanged",
+			a:    "a\nb\nc",
+			b:    "x\nb\nc",
+			want: `
+--- a
++++ b
+@@ -1,3 +1,3 @@
+-a
++x
+ b
+ c
+\ No newline at end of file
+`[1:],
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			buf := new(bytes.Buffer)
+			if err := diff.Text("a", "b", test.a, test.b, buf); err != nil {
+				t.Fatal(err)
+			}
+			if got := buf.String(); got != test.want {
+				t.Errorf("bad diff:\ngot:\n%s\nwant:\n%s", got, test.want)
+			}
+		})
+	}
+}
+
+func TestLineEndings(t *testing.T) {
+	a := "a\r\nb\r\nc\r\n"
+	b := "a\nb\r\nc\nd\n"
+	tests := []struct {
+		name string
+		opts []write.Option
+		want string
+	}{
+		{
+			name: "Default",
+			want: "--- a\n+++ b\n@@ -1,3 +1,4 @@\n-a\r\n+a\n b\r\n-c\r\n+c\n+d\n",
+		},
+		{
+			name: "IgnoreCRAtEOL",
+			opts: []write.Option{diff.IgnoreCRAtEOL()},
+			want: "--- a\n+++ b\n@@ -1,3 +1,4 @@\n a\r\n b\r\n c\r\n+d\n",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			buf := new(bytes.Buffer)
+			if err := diff.Text("a", "b", a, b, buf, test.opts...); err != nil {
+				t.Fatal(err)
+			}
+			if got := buf.String(); got != test.want {
+				t.Errorf("bad diff:\ngot:  %q\nwant: %q", got, test.want)
+			}
+		})
+	}
+}
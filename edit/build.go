@@ -0,0 +1,38 @@
+package edit
+
+import "github.com/pkg/diff/internal/build"
+
+// A builder builds a Script, operation by operation, using a build.Builder.
+type builder struct {
+	build.Builder
+}
+
+// add appends n elements of operation op, which must be Eq, Ins, or Del.
+func (s *builder) add(op Op, n int) {
+	switch op {
+	case Eq:
+		s.Eq(n)
+	case Del:
+		s.Del(n)
+	case Ins:
+		s.Ins(n)
+	default:
+		panic("edit: builder.add called with op " + op.String())
+	}
+}
+
+// script returns the Script built so far.
+func (s *builder) script() Script {
+	var e Script
+	for _, r := range s.Ranges() {
+		e.Ranges = append(e.Ranges, Range{LowA: r.LowA, HighA: r.HighA, LowB: r.LowB, HighB: r.HighB})
+	}
+	return e
+}
+
+func min(x, y int) int {
+	if x < y {
+		return x
+	}
+	return y
+}
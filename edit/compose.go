@@ -0,0 +1,123 @@
+package edit
+
+import "fmt"
+
+// Compose returns an edit script to alter A into C,
+// given an edit script ab to alter A into B
+// and an edit script bc to alter B into C.
+// It does not need the contents of A, B, or C.
+//
+// ab and bc must each cover all of their inputs,
+// as the edit scripts returned by myers.Diff do.
+// To compose edit scripts with reduced context,
+// compose them first, and then reduce the context of the result.
+// Compose returns an error if ab and bc do not cover their inputs,
+// or if the length of B according to ab differs from the length of B according to bc.
+//
+// Replacements in ab and bc are split into deletions and insertions.
+// The result has the same shape as the edit scripts returned by myers.Diff.
+func Compose(ab, bc Script) (Script, error) {
+	ab, bc = ab.SplitReplacements(), bc.SplitReplacements()
+	if err := checkContiguous("ab", ab); err != nil {
+		return Script{}, err
+	}
+	if err := checkContiguous("bc", bc); err != nil {
+		return Script{}, err
+	}
+	if lenB, lenB2 := ab.lenB(), bc.lenA(); lenB != lenB2 {
+		return Script{}, fmt.Errorf("edit: cannot compose scripts: B has length %d in ab and %d in bc", lenB, lenB2)
+	}
+
+	var s builder
+	// i and j are the indices of the current ranges of ab and bc.
+	// di and dj are the number of elements of B already consumed from those ranges.
+	i, j, di, dj := 0, 0, 0, 0
+	for {
+		// Handle ranges that do not involve B.
+		if i < len(ab.Ranges) && ab.Ranges[i].IsDelete() {
+			s.add(Del, ab.Ranges[i].Len())
+			i++
+			continue
+		}
+		if j < len(bc.Ranges) && bc.Ranges[j].IsInsert() {
+			s.add(Ins, bc.Ranges[j].Len())
+			j++
+			continue
+		}
+		if i == len(ab.Ranges) || j == len(bc.Ranges) {
+			break
+		}
+
+		// Both ranges involve B. Consume as much of B as they share.
+		r, q := &ab.Ranges[i], &bc.Ranges[j]
+		n := min(r.HighB-r.LowB-di, q.HighA-q.LowA-dj)
+		switch {
+		case r.IsEqual() && q.IsEqual():
+			s.add(Eq, n)
+		case r.IsEqual():
+			// An element of A was kept in B, then deleted in C.
+			s.add(Del, n)
+		case q.IsEqual():
+			// An element was inserted in B, then kept in C.
+			s.add(Ins, n)
+		default:
+			// An element was inserted in B, then deleted in C.
+		}
+		di += n
+		dj += n
+		if di == r.HighB-r.LowB {
+			i++
+			di = 0
+		}
+		if dj == q.HighA-q.LowA {
+			j++
+			dj = 0
+		}
+	}
+	return s.script(), nil
+}
+
+// checkContiguous reports an error if s, named name,
+// does not cover all of its inputs, starting at the beginning.
+// An insertion may be anchored anywhere in A,
+// and a deletion anywhere in B.
+func checkContiguous(name string, s Script) error {
+	a, b := 0, 0
+	for _, r := range s.Ranges {
+		if !r.IsInsert() {
+			if r.LowA != a {
+				return fmt.Errorf("edit: %s is not contiguous: range %v starts at %d in A, want %d", name, r, r.LowA, a)
+			}
+			a = r.HighA
+		}
+		if !r.IsDelete() {
+			if r.LowB != b {
+				return fmt.Errorf("edit: %s is not contiguous: range %v starts at %d in B, want %d", name, r, r.LowB, b)
+			}
+			b = r.HighB
+		}
+	}
+	return nil
+}
+
+// lenA returns the length of A covered by s.
+func (s *Script) lenA() int {
+	n := 0
+	for _, r := range s.Ranges {
+		if !r.IsInsert() {
+			n = r.HighA
+		}
+	}
+	return n
+}
+
+// lenB returns the length of B covered by s.
+func (s *Script) lenB() int {
+	n := 0
+	for _, r := range s.Ranges {
+		if !r.IsDelete() {
+			n = r.HighB
+		}
+	}
+	return n
+}
@@ -39,11 +39,42 @@ func (s *Script) Stat() (ins, del int) {
 			del += r.HighA - r.LowA
 		case r.IsInsert():
 			ins += r.HighB - r.LowB
+		case r.IsReplace():
+			del += r.HighA - r.LowA
+			ins += r.HighB - r.LowB
 		}
 	}
 	return ins, del
 }
 
+// Reverse returns the inverse of s, an edit script to alter B into A.
+// Deletions in s become insertions, and insertions become deletions.
+// Where s deletes and then inserts, so does the result,
+// so that it has the same shape as the edit scripts returned by myers.Diff.
+// s may have had its context reduced, as by ctxt.Size.
+func (s *Script) Reverse() Script {
+	if len(s.Ranges) == 0 {
+		return Script{}
+	}
+	out := make([]Range, len(s.Ranges))
+	for i, r := range s.Ranges {
+		out[i] = Range{LowA: r.LowB, HighA: r.HighB, LowB: r.LowA, HighB: r.HighA, Replace: r.Replace}
+	}
+	for i := 0; i+1 < len(out); i++ {
+		ins, del := &out[i], &out[i+1]
+		if ins.IsDelete() || !ins.IsInsert() || !del.IsDelete() || del.IsInsert() {
+			continue
+		}
+		// Swap the insertion and the deletion,
+		// positioning the insertion after the deleted elements of A,
+		// and the deletion before the inserted elements of B.
+		*ins, *del = Range{LowA: del.LowA, HighA: del.HighA, LowB: ins.LowB, HighB: ins.LowB},
+			Range{LowA: del.HighA, HighA: del.HighA, LowB: ins.LowB, HighB: ins.HighB}
+		i++
+	}
+	return Script{Ranges: out}
+}
+
 // dump formats s for debugging.
 func (s *Script) dump() string {
 	buf := new(strings.Builder)
@@ -58,24 +89,38 @@ func (s *Script) dump() string {
 type Range struct {
 	LowA, HighA int
 	LowB, HighB int
+
+	// Replace reports whether r is a replacement:
+	// a deletion of A[LowA:HighA] paired with an insertion of B[LowB:HighB].
+	// Without it, a range whose A and B elements have the same length
+	// represents equal elements.
+	// Edit scripts contain replacements only if they are added explicitly,
+	// such as by Script.JoinReplacements.
+	Replace bool
 }
 
 // IsInsert reports whether r represents an insertion in a Script.
 // If so, the inserted elements are B[LowB:HighB].
 func (r *Range) IsInsert() bool {
-	return r.LowA == r.HighA
+	return !r.Replace && r.LowA == r.HighA
 }
 
 // IsDelete reports whether r represents a deletion in a Script.
 // If so, the deleted elements are A[LowA:HighA].
 func (r *Range) IsDelete() bool {
-	return r.LowB == r.HighB
+	return !r.Replace && r.LowB == r.HighB
 }
 
 // IsEqual reports whether r represents a series of equal elements in a Script.
 // If so, the elements A[LowA:HighA] are equal to the elements B[LowB:HighB].
 func (r *Range) IsEqual() bool {
-	return r.HighB-r.LowB == r.HighA-r.LowA
+	return !r.Replace && r.HighB-r.LowB == r.HighA-r.LowA
+}
+
+// IsReplace reports whether r represents a replacement in a Script.
+// If so, the elements A[LowA:HighA] are replaced by the elements B[LowB:HighB].
+func (r *Range) IsReplace() bool {
+	return r.Replace
 }
 
 // An Op is a edit operation in a Script.
@@ -84,16 +129,20 @@ type Op int8
 //go:generate stringer -type Op
 
 const (
-	Del Op = -1 // delete
-	Eq  Op = 0  // equal
-	Ins Op = 1  // insert
+	Del     Op = -1 // delete
+	Eq      Op = 0  // equal
+	Ins     Op = 1  // insert
+	Replace Op = 2  // replace: delete, then insert
 )
 
 // Op reports what kind of operation r represents.
 // This can also be determined by calling r.IsInsert,
-// r.IsDelete, and r.IsEqual,
+// r.IsDelete, r.IsEqual, and r.IsReplace,
 // but this form is sometimes more convenient to use.
 func (r *Range) Op() Op {
+	if r.IsReplace() {
+		return Replace
+	}
 	if r.IsInsert() {
 		return Ins
 	}
@@ -110,9 +159,65 @@ func (r *Range) Op() Op {
 // In a deletion, it is the number of deleted elements.
 // In an insertion, it is the number of inserted elements.
 // For equal elements, it is the number of equal elements.
+// In a replacement, it is the number of deleted elements.
 func (r *Range) Len() int {
 	if r.LowA == r.HighA {
 		return r.HighB - r.LowB
 	}
 	return r.HighA - r.LowA
 }
+
+// JoinReplacements returns an equivalent edit script in which each deletion
+// that is immediately followed by an insertion, or vice versa,
+// is combined into a single replacement.
+// s must be well-formed, as reported by Validate.
+func (s *Script) JoinReplacements() Script {
+	var out []Range
+	for i := 0; i < len(s.Ranges); i++ {
+		r := s.Ranges[i]
+		if i+1 < len(s.Ranges) && r.Len() > 0 {
+			next := anchor(s.Ranges, i+1)
+			if next.Len() > 0 {
+				switch {
+				case r.IsDelete() && next.IsInsert() && next.LowA == r.HighA && next.LowB == r.LowB:
+					out = append(out, Range{LowA: r.LowA, HighA: r.HighA, LowB: next.LowB, HighB: next.HighB, Replace: true})
+					i++
+					continue
+				case r.IsInsert() && next.IsDelete() && next.LowA == r.LowA && next.LowB == r.HighB:
+					out = append(out, Range{LowA: next.LowA, HighA: next.HighA, LowB: r.LowB, HighB: r.HighB, Replace: true})
+					i++
+					continue
+				}
+			}
+		}
+		out = append(out, r)
+	}
+	return Script{Ranges: out}
+}
+
+// SplitReplacements returns an equivalent edit script in which each replacement
+// is split into a deletion followed by an insertion,
+// as in the edit scripts returned by myers.Diff.
+func (s *Script) SplitReplacements() Script {
+	var out []Range
+	for _, r := range s.Ranges {
+		if !r.IsReplace() {
+			out = append(out, r)
+			continue
+		}
+		del, ins := r.Split()
+		out = append(out, del, ins)
+	}
+	return Script{Ranges: out}
+}
+
+// Split splits the replacement r into a deletion followed by an insertion.
+// If r is not a replacement, Split panics.
+func (r *Range) Split() (del, ins Range) {
+	if !r.IsReplace() {
+		panic("edit: Split called on a Range that is not a replacement")
+	}
+	del = Range{LowA: r.LowA, HighA: r.HighA, LowB: r.LowB, HighB: r.LowB}
+	ins = Range{LowA: r.HighA, HighA: r.HighA, LowB: r.LowB, HighB: r.HighB}
+	return del, ins
+}
@@ -0,0 +1,358 @@
+package edit_test
+
+import (
+	"context"
+	"math/rand"
+	"reflect"
+	"testing"
+
+	"github.com/pkg/diff/ctxt"
+	"github.com/pkg/diff/edit"
+	"github.com/pkg/diff/internal/difftest"
+	"github.com/pkg/diff/myers"
+)
+
+// randString returns a random string of up to 20 bytes.
+func randString(r *rand.Rand) string {
+	return difftest.RandString(r, r.Intn(21), "abc")
+}
+
+// apply applies the complete edit script e to a, taking insertions from b.
+func apply(t *testing.T, e edit.Script, a, b string) string {
+	t.Helper()
+	var out []byte
+	ai, bi := 0, 0
+	for _, r := range e.Ranges {
+		if r.LowA != ai || r.LowB != bi {
+			t.Fatalf("discontiguous script %v", e)
+		}
+		switch r.Op() {
+		case edit.Eq:
+			if a[r.LowA:r.HighA] != b[r.LowB:r.HighB] {
+				t.Fatalf("range %v of script %v is not equal", r, e)
+			}
+			out = append(out, a[r.LowA:r.HighA]...)
+		case edit.Ins:
+			out = append(out, b[r.LowB:r.HighB]...)
+		}
+		ai, bi = r.HighA, r.HighB
+	}
+	if ai != len(a) || bi != len(b) {
+		t.Fatalf("incomplete script %v", e)
+	}
+	return string(out)
+}
+
+func TestReverse(t *testing.T) {
+	s := edit.NewScript(
+		edit.Range{LowA: 0, HighA: 1, LowB: 0, HighB: 1},
+		edit.Range{LowA: 1, HighA: 3, LowB: 1, HighB: 1},
+		edit.Range{LowA: 3, HighA: 3, LowB: 1, HighB: 2},
+		edit.Range{LowA: 3, HighA: 4, LowB: 2, HighB: 3},
+		edit.Range{LowA: 4, HighA: 4, LowB: 3, HighB: 5},
+	)
+	want := edit.NewScript(
+		edit.Range{LowA: 0, HighA: 1, LowB: 0, HighB: 1},
+		edit.Range{LowA: 1, HighA: 2, LowB: 1, HighB: 1},
+		edit.Range{LowA: 2, HighA: 2, LowB: 1, HighB: 3},
+		edit.Range{LowA: 2, HighA: 3, LowB: 3, HighB: 4},
+		edit.Range{LowA: 3, HighA: 5, LowB: 4, HighB: 4},
+	)
+	if got := s.Reverse(); !reflect.DeepEqual(got, want) {
+		t.Errorf("Reverse() = %v, want %v", got, want)
+	}
+	if got := want.Reverse(); !reflect.DeepEqual(got, s) {
+		t.Errorf("Reverse(Reverse()) = %v, want %v", got, s)
+	}
+}
+
+func TestReverseRandom(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	for i := 0; i < 1000; i++ {
+		a, b := randString(r), randString(r)
+		e := myers.Diff(context.Background(), &difftest.Bytes{A: a, B: b})
+		rev := e.Reverse()
+		if err := difftest.CheckScript(b, a, rev); err != nil {
+			t.Fatal(err)
+		}
+		if got := apply(t, rev, b, a); got != a {
+			t.Fatalf("reverse of diff(%q, %q) produces %q", a, b, got)
+		}
+		// Reducing context commutes with reversing.
+		sized := ctxt.Size(e, 1)
+		if got, want := sized.Reverse(), ctxt.Size(rev, 1); !reflect.DeepEqual(got, want) {
+			t.Fatalf("diff(%q, %q): Reverse(Size(e)) = %v, want %v", a, b, got, want)
+		}
+	}
+}
+
+func TestCompose(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	for i := 0; i < 1000; i++ {
+		a, b, c := randString(r), randString(r), randString(r)
+		ab := myers.Diff(context.Background(), &difftest.Bytes{A: a, B: b})
+		bc := myers.Diff(context.Background(), &difftest.Bytes{A: b, B: c})
+		ac, err := edit.Compose(ab, bc)
+		if err != nil {
+			t.Fatalf("Compose(diff(%q, %q), diff(%q, %q)): %v", a, b, b, c, err)
+		}
+		if err := difftest.CheckScript(a, c, ac); err != nil {
+			t.Fatal(err)
+		}
+		if got := apply(t, ac, a, c); got != c {
+			t.Fatalf("Compose(diff(%q, %q), diff(%q, %q)) produces %q", a, b, b, c, got)
+		}
+	}
+}
+
+func TestComposeError(t *testing.T) {
+	ab := myers.Diff(context.Background(), &difftest.Bytes{A: "abc", B: "abd"})
+	bc := myers.Diff(context.Background(), &difftest.Bytes{A: "abdx", B: "ab"})
+	if _, err := edit.Compose(ab, bc); err == nil {
+		t.Errorf("Compose with different lengths of B succeeded")
+	}
+	sized := ctxt.Size(myers.Diff(context.Background(), &difftest.Bytes{A: "abcdefghij", B: "xbcdefghiy"}), 1)
+	if _, err := edit.Compose(sized, sized); err == nil {
+		t.Errorf("Compose with discontiguous scripts succeeded")
+	}
+}
+
+func TestValidate(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	for i := 0; i < 1000; i++ {
+		a, b := randString(r), randString(r)
+		e := myers.Diff(context.Background(), &difftest.Bytes{A: a, B: b})
+		for _, n := range []int{0, 1, 3} {
+			sized := ctxt.Size(e, n)
+			if err := sized.Validate(len(a), len(b)); err != nil {
+				t.Fatalf("ctxt.Size(diff(%q, %q), %d): %v", a, b, n, err)
+			}
+		}
+		if err := e.Validate(len(a), len(b)); err != nil {
+			t.Fatalf("diff(%q, %q): %v", a, b, err)
+		}
+	}
+
+	tests := []struct {
+		name       string
+		s          edit.Script
+		lenA, lenB int
+	}{
+		{
+			name: "OutOfBoundsA",
+			s:    edit.NewScript(edit.Range{LowA: 0, HighA: 3, LowB: 0, HighB: 0}),
+			lenA: 2, lenB: 0,
+		},
+		{
+			name: "OutOfBoundsB",
+			s:    edit.NewScript(edit.Range{LowA: 0, HighA: 0, LowB: -1, HighB: 1}),
+			lenA: 0, lenB: 1,
+		},
+		{
+			name: "Backwards",
+			s:    edit.NewScript(edit.Range{LowA: 2, HighA: 1, LowB: 0, HighB: 0}),
+			lenA: 2, lenB: 0,
+		},
+		{
+			name: "Malformed",
+			s:    edit.NewScript(edit.Range{LowA: 0, HighA: 1, LowB: 0, HighB: 2}),
+			lenA: 1, lenB: 2,
+		},
+		{
+			name: "Overlap",
+			s: edit.NewScript(
+				edit.Range{LowA: 0, HighA: 2, LowB: 0, HighB: 2},
+				edit.Range{LowA: 1, HighA: 2, LowB: 2, HighB: 2},
+			),
+			lenA: 2, lenB: 2,
+		},
+		{
+			name: "UnevenGap",
+			s: edit.NewScript(
+				edit.Range{LowA: 0, HighA: 1, LowB: 0, HighB: 0},
+				edit.Range{LowA: 3, HighA: 4, LowB: 1, HighB: 2},
+			),
+			lenA: 4, lenB: 2,
+		},
+		{
+			name: "UnevenEnd",
+			s:    edit.NewScript(edit.Range{LowA: 0, HighA: 1, LowB: 0, HighB: 0}),
+			lenA: 3, lenB: 1,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if err := test.s.Validate(test.lenA, test.lenB); err == nil {
+				t.Errorf("Validate(%d, %d) of %v succeeded", test.lenA, test.lenB, test.s)
+			}
+		})
+	}
+}
+
+func TestNormalize(t *testing.T) {
+	s := edit.NewScript(
+		edit.Range{LowA: 0, HighA: 1, LowB: 0, HighB: 1},
+		edit.Range{LowA: 1, HighA: 2, LowB: 1, HighB: 2},
+		edit.Range{LowA: 2, HighA: 2, LowB: 2, HighB: 2},
+		edit.Range{LowA: 2, HighA: 2, LowB: 2, HighB: 3},
+		edit.Range{LowA: 2, HighA: 3, LowB: 3, HighB: 3},
+		edit.Range{LowA: 3, HighA: 4, LowB: 3, HighB: 3},
+		edit.Range{LowA: 4, HighA: 5, LowB: 3, HighB: 4},
+		// gap
+		edit.Range{LowA: 8, HighA: 9, LowB: 7, HighB: 8},
+		edit.Range{LowA: 9, HighA: 9, LowB: 8, HighB: 9},
+	)
+	want := edit.NewScript(
+		edit.Range{LowA: 0, HighA: 2, LowB: 0, HighB: 2},
+		edit.Range{LowA: 2, HighA: 4, LowB: 2, HighB: 2},
+		edit.Range{LowA: 4, HighA: 4, LowB: 2, HighB: 3},
+		edit.Range{LowA: 4, HighA: 5, LowB: 3, HighB: 4},
+		edit.Range{LowA: 8, HighA: 9, LowB: 7, HighB: 8},
+		edit.Range{LowA: 9, HighA: 9, LowB: 8, HighB: 9},
+	)
+	if err := s.Validate(9, 9); err != nil {
+		t.Fatal(err)
+	}
+	got := s.Normalize()
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Normalize() = %v, want %v", got, want)
+	}
+
+	// Normalizing the output of myers.Diff only moves
+	// insertions anchored at the start of a deletion.
+	e := myers.Diff(context.Background(), &difftest.Bytes{A: "abc", B: "xyz"})
+	want = edit.NewScript(
+		edit.Range{LowA: 0, HighA: 3, LowB: 0, HighB: 0},
+		edit.Range{LowA: 3, HighA: 3, LowB: 0, HighB: 3},
+	)
+	if got := e.Normalize(); !reflect.DeepEqual(got, want) {
+		t.Errorf("Normalize() = %v, want %v", got, want)
+	}
+
+	// A deletion after an insertion is not merged
+	// with a deletion before a gap.
+	s = edit.NewScript(
+		edit.Range{LowA: 0, HighA: 1, LowB: 0, HighB: 0},
+		// gap
+		edit.Range{LowA: 3, HighA: 3, LowB: 2, HighB: 3},
+		edit.Range{LowA: 3, HighA: 4, LowB: 3, HighB: 3},
+	)
+	want = edit.NewScript(
+		edit.Range{LowA: 0, HighA: 1, LowB: 0, HighB: 0},
+		edit.Range{LowA: 3, HighA: 4, LowB: 2, HighB: 2},
+		edit.Range{LowA: 4, HighA: 4, LowB: 2, HighB: 3},
+	)
+	if err := s.Validate(4, 3); err != nil {
+		t.Fatal(err)
+	}
+	if got := s.Normalize(); !reflect.DeepEqual(got, want) {
+		t.Errorf("Normalize() = %v, want %v", got, want)
+	}
+	e = myers.Diff(context.Background(), &difftest.Bytes{A: "abcd", B: "xbcy"})
+	if got := e.Normalize(); !reflect.DeepEqual(got, e) {
+		t.Errorf("Normalize() = %v, want %v", got, e)
+	}
+}
+
+func TestReplace(t *testing.T) {
+	r := edit.Range{LowA: 1, HighA: 3, LowB: 1, HighB: 3, Replace: true}
+	if r.IsEqual() || r.IsInsert() || r.IsDelete() || !r.IsReplace() {
+		t.Errorf("replacement %v reports the wrong kind", r)
+	}
+	if op := r.Op(); op != edit.Replace || op.String() != "Replace" {
+		t.Errorf("Op() = %v, want Replace", op)
+	}
+	del, ins := r.Split()
+	if want := (edit.Range{LowA: 1, HighA: 3, LowB: 1, HighB: 1}); del != want {
+		t.Errorf("Split() deletion = %v, want %v", del, want)
+	}
+	if want := (edit.Range{LowA: 3, HighA: 3, LowB: 1, HighB: 3}); ins != want {
+		t.Errorf("Split() insertion = %v, want %v", ins, want)
+	}
+
+	s := edit.NewScript(edit.Range{LowA: 0, HighA: 1, LowB: 0, HighB: 1}, r)
+	if ins, del := s.Stat(); ins != 2 || del != 2 {
+		t.Errorf("Stat() = %d, %d, want 2, 2", ins, del)
+	}
+	if err := s.Validate(3, 3); err != nil {
+		t.Error(err)
+	}
+	empty := edit.NewScript(edit.Range{LowA: 0, HighA: 1, LowB: 0, HighB: 0, Replace: true})
+	if err := empty.Validate(1, 0); err == nil {
+		t.Errorf("Validate of replacement with no elements in B succeeded")
+	}
+}
+
+func TestJoinReplacements(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	for i := 0; i < 1000; i++ {
+		a, b := randString(r), randString(r)
+		e := myers.Diff(context.Background(), &difftest.Bytes{A: a, B: b})
+		joined := e.JoinReplacements()
+		if err := joined.Validate(len(a), len(b)); err != nil {
+			t.Fatalf("diff(%q, %q): %v", a, b, err)
+		}
+		for j, r := range joined.Ranges {
+			if j > 0 && !r.IsEqual() && !joined.Ranges[j-1].IsEqual() {
+				t.Fatalf("diff(%q, %q): adjacent changes in joined script %v", a, b, joined)
+			}
+		}
+		split := joined.SplitReplacements()
+		if got, want := split.Normalize(), e.Normalize(); !reflect.DeepEqual(got, want) {
+			t.Fatalf("diff(%q, %q): split(join(e)) = %v, want %v", a, b, got, want)
+		}
+		rev := joined.Reverse()
+		if got := apply(t, rev.SplitReplacements(), b, a); got != a {
+			t.Fatalf("reverse of joined diff(%q, %q) produces %q", a, b, got)
+		}
+	}
+}
+
+func TestHunks(t *testing.T) {
+	e := myers.Diff(context.Background(), &difftest.Bytes{A: "abcdefghij", B: "xbcdefgjy"})
+	s := ctxt.Size(e, 1)
+	want := []edit.Hunk{
+		{
+			LowA: 0, HighA: 2, LowB: 0, HighB: 2,
+			Ranges: []edit.Range{
+				{LowA: 0, HighA: 1, LowB: 0, HighB: 0},
+				{LowA: 1, HighA: 1, LowB: 0, HighB: 1},
+				{LowA: 1, HighA: 2, LowB: 1, HighB: 2},
+			},
+		},
+		{
+			LowA: 6, HighA: 10, LowB: 6, HighB: 9,
+			Ranges: []edit.Range{
+				{LowA: 6, HighA: 7, LowB: 6, HighB: 7},
+				{LowA: 7, HighA: 9, LowB: 7, HighB: 7},
+				{LowA: 9, HighA: 10, LowB: 7, HighB: 8},
+				{LowA: 10, HighA: 10, LowB: 8, HighB: 9},
+			},
+		},
+	}
+	var got []edit.Hunk
+	for it := s.Hunks(); it.Next(); {
+		got = append(got, it.Hunk())
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Hunks() = %v, want %v", got, want)
+	}
+
+	// An insertion anchored at the start of a deletion does not start a new hunk.
+	e = myers.Diff(context.Background(), &difftest.Bytes{A: "abc", B: "xy"})
+	it := e.Hunks()
+	if !it.Next() {
+		t.Fatalf("no hunks in %v", e)
+	}
+	if h := it.Hunk(); h.LowA != 0 || h.HighA != 3 || h.LowB != 0 || h.HighB != 2 || len(h.Ranges) != 2 {
+		t.Errorf("Hunk() = %v, want a single hunk covering everything", h)
+	}
+	if it.Next() {
+		t.Errorf("extra hunk %v", it.Hunk())
+	}
+
+	var empty edit.Script
+	if empty.Hunks().Next() {
+		t.Errorf("empty script has a hunk")
+	}
+}
@@ -0,0 +1,69 @@
+package edit
+
+// A Hunk is a run of contiguous ranges in a Script,
+// such as a single "@@" section of a unified diff.
+type Hunk struct {
+	// The hunk covers the elements A[LowA:HighA] and B[LowB:HighB].
+	// If it covers no elements of A, LowA and HighA are the position
+	// in A at which its elements of B are inserted, and vice versa.
+	LowA, HighA int
+	LowB, HighB int
+	// Ranges are the ranges of the hunk.
+	Ranges []Range
+}
+
+// A HunkIter iterates over the hunks of a Script.
+type HunkIter struct {
+	ranges []Range
+	next   int // index of the first range of the next hunk
+	h      Hunk
+}
+
+// Hunks returns an iterator over the hunks of s.
+// Each hunk is a maximal run of contiguous ranges.
+// An edit script whose context has not been reduced, as by ctxt.Size,
+// has at most one hunk.
+//
+// A typical loop is:
+//
+//	for it := s.Hunks(); it.Next(); {
+//		h := it.Hunk()
+//		// ...
+//	}
+func (s *Script) Hunks() *HunkIter {
+	return &HunkIter{ranges: s.Ranges}
+}
+
+// Next advances it to the next hunk, which is then available through Hunk.
+// It reports whether there is a next hunk.
+func (it *HunkIter) Next() bool {
+	i := it.next
+	if i >= len(it.ranges) {
+		it.h = Hunk{}
+		return false
+	}
+	first := anchor(it.ranges, i)
+	last := first
+	j := i + 1
+	for ; j < len(it.ranges); j++ {
+		r := anchor(it.ranges, j)
+		if r.LowA != last.HighA || r.LowB != last.HighB {
+			// discontiguous edit script
+			break
+		}
+		last = r
+	}
+	it.h = Hunk{
+		LowA: first.LowA, HighA: last.HighA,
+		LowB: first.LowB, HighB: last.HighB,
+		Ranges: it.ranges[i:j],
+	}
+	it.next = j
+	return true
+}
+
+// Hunk returns the current hunk.
+// Its Ranges alias those of the Script.
+func (it *HunkIter) Hunk() Hunk {
+	return it.h
+}
@@ -11,11 +11,12 @@ func _() {
 	_ = x[Del - -1]
 	_ = x[Eq-0]
 	_ = x[Ins-1]
+	_ = x[Replace-2]
 }
 
-const _Op_name = "DelEqIns"
+const _Op_name = "DelEqInsReplace"
 
-var _Op_index = [...]uint8{0, 3, 5, 8}
+var _Op_index = [...]uint8{0, 3, 5, 8, 15}
 
 func (i Op) String() string {
 	i -= -1
@@ -0,0 +1,84 @@
+package edit
+
+import "fmt"
+
+// Validate reports whether s is a well-formed edit script
+// to alter an A of length lenA into a B of length lenB.
+// If not, it returns an error describing the first problem found.
+//
+// In a well-formed edit script, each range is an insertion, a deletion,
+// a replacement, or a series of equal elements, and lies within A and B.
+// The ranges are in order and do not overlap.
+// Elements between ranges are implicitly equal, as in an edit script
+// whose context has been reduced by ctxt.Size,
+// so any gap between ranges, and after the last range,
+// must have the same length in A and B.
+//
+// As a special case, for compatibility with myers.Diff,
+// an insertion that immediately follows a deletion
+// may be anchored at the start of the deletion rather than its end.
+func (s *Script) Validate(lenA, lenB int) error {
+	a, b := 0, 0 // end of the previous range in A and B
+	for i, r := range s.Ranges {
+		if r.LowA < 0 || r.LowA > r.HighA || r.HighA > lenA {
+			return fmt.Errorf("edit: range %d %v: A range out of bounds [0, %d]", i, r, lenA)
+		}
+		if r.LowB < 0 || r.LowB > r.HighB || r.HighB > lenB {
+			return fmt.Errorf("edit: range %d %v: B range out of bounds [0, %d]", i, r, lenB)
+		}
+		if r.IsReplace() && (r.LowA == r.HighA || r.LowB == r.HighB) {
+			return fmt.Errorf("edit: range %d %v: replacement with no elements in A or B", i, r)
+		}
+		if !r.IsInsert() && !r.IsDelete() && !r.IsEqual() && !r.IsReplace() {
+			return fmt.Errorf("edit: range %d %v: not an insertion, deletion, replacement, or equal elements", i, r)
+		}
+		r = anchor(s.Ranges, i)
+		if r.LowA < a || r.LowB < b {
+			return fmt.Errorf("edit: range %d %v: out of order or overlaps previous range", i, s.Ranges[i])
+		}
+		if r.LowA-a != r.LowB-b {
+			return fmt.Errorf("edit: range %d %v: gap before range has length %d in A and %d in B", i, s.Ranges[i], r.LowA-a, r.LowB-b)
+		}
+		a, b = r.HighA, r.HighB
+	}
+	if lenA-a != lenB-b {
+		return fmt.Errorf("edit: gap after last range has length %d in A and %d in B", lenA-a, lenB-b)
+	}
+	return nil
+}
+
+// anchor returns s[i], with its position adjusted
+// if it is an insertion anchored at the start of the preceding deletion,
+// as in the output of myers.Diff when A and B have nothing in common.
+func anchor(s []Range, i int) Range {
+	r := s[i]
+	if i == 0 || !r.IsInsert() || r.IsDelete() {
+		return r
+	}
+	if prev := s[i-1]; !prev.IsInsert() && prev.IsDelete() && r.LowA == prev.LowA && r.LowB == prev.LowB {
+		r.LowA, r.HighA = prev.HighA, prev.HighA
+	}
+	return r
+}
+
+// Normalize returns an equivalent edit script with empty ranges removed,
+// replacements split, adjacent ranges of the same kind merged,
+// and deletions placed before insertions,
+// giving it the same shape as the edit scripts returned by myers.Diff.
+// Gaps between ranges, as in an edit script whose context has been reduced,
+// are preserved.
+// s must be well-formed, as reported by Validate.
+func (s *Script) Normalize() Script {
+	t := s.SplitReplacements()
+	var e builder
+	for i := range t.Ranges {
+		r := anchor(t.Ranges, i)
+		if r.LowA == r.HighA && r.LowB == r.HighB {
+			continue
+		}
+		x, _ := e.Pos()
+		e.Skip(r.LowA - x)
+		e.add(r.Op(), r.Len())
+	}
+	return e.script()
+}
@@ -4,9 +4,11 @@ import (
 	"os"
 
 	"github.com/pkg/diff"
+	"github.com/pkg/diff/funcname"
+	"github.com/pkg/diff/patience"
 )
 
-func Example_Slices() {
+func ExampleSlices() {
 	want := []int{1, 2, 3, 4, 5, 6, 7, 8, 9}
 	got := []int{1, 2, 3, 4, 6, 7, 8, 9}
 	err := diff.Slices("want", "got", want, got, os.Stdout)
@@ -26,7 +28,7 @@ func Example_Slices() {
 	//  8
 }
 
-func Example_Text() {
+func ExampleText() {
 	a := `
 a
 b
@@ -50,3 +52,52 @@ d
 	//  c
 	// +d
 }
+
+func ExampleWithAlgorithm() {
+	a := []string{"{", "a", "}", "{", "c", "}"}
+	b := []string{"{", "a", "}", "{", "b", "}", "{", "c", "}"}
+	err := diff.Slices("a", "b", a, b, os.Stdout, diff.WithAlgorithm(patience.Diff), diff.ContextLines(1))
+	if err != nil {
+		panic(err)
+	}
+	// Output:
+	// --- a
+	// +++ b
+	// @@ -4,2 +4,5 @@
+	//  {
+	// +b
+	// +}
+	// +{
+	//  c
+}
+
+func ExampleFuncNames() {
+	a := `
+package main
+
+func main() {
+	fmt.Println("hello")
+	fmt.Println("world")
+}
+`[1:]
+	b := `
+package main
+
+func main() {
+	fmt.Println("hello")
+	fmt.Println("gophers")
+}
+`[1:]
+	err := diff.Text("a", "b", a, b, os.Stdout, diff.FuncNames(funcname.Go), diff.ContextLines(1))
+	if err != nil {
+		panic(err)
+	}
+	// Output:
+	// --- a
+	// +++ b
+	// @@ -4,3 +4,3 @@ func main() {
+	//  	fmt.Println("hello")
+	// -	fmt.Println("world")
+	// +	fmt.Println("gophers")
+	//  }
+}
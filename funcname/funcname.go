@@ -0,0 +1,185 @@
+// Package funcname finds the function-like lines that head the sections of a text,
+// such as the "func Foo" in a unified diff hunk header "@@ -1,5 +1,6 @@ func Foo".
+//
+// It mimics git's diff.<driver>.xfuncname support.
+// The Matcher for a section header is usually a Regexp,
+// and the package provides Regexps equivalent to git's built-in patterns
+// for several common languages.
+// For Go source, GoHeaders parses the text instead, for more accurate headers.
+// Use Headers or GoHeaders with write.SectionHeaders to add headers to a unified diff.
+package funcname
+
+import (
+	"errors"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+)
+
+// A Matcher recognizes lines that begin a section of a text.
+type Matcher interface {
+	// Match reports whether line, which has no line terminator, begins a section.
+	// If so, it returns the text describing the section,
+	// typically all or part of line.
+	Match(line string) (header string, ok bool)
+}
+
+// A Regexp is a Matcher that uses a list of regular expressions,
+// as in git's xfuncname configuration.
+type Regexp struct {
+	pats []pattern
+}
+
+type pattern struct {
+	re     *regexp.Regexp
+	negate bool
+}
+
+// Compile parses a list of regular expressions, one per line,
+// and returns a Regexp that matches lines using them.
+//
+// A line is matched against the expressions in order.
+// The first expression that matches determines the result.
+// If that expression begins with "!", the line does not begin a section.
+// Otherwise, the header is the text matched by the expression's first
+// parenthesized subexpression, or the entire match if it has none,
+// with trailing white space removed.
+// A line that matches no expression does not begin a section.
+//
+// As in git, the expressions use POSIX leftmost-longest matching.
+func Compile(expr string) (*Regexp, error) {
+	if expr == "" {
+		return nil, errors.New("funcname: empty pattern list")
+	}
+	var m Regexp
+	for _, line := range strings.Split(expr, "\n") {
+		var p pattern
+		if strings.HasPrefix(line, "!") {
+			p.negate = true
+			line = line[1:]
+		}
+		re, err := regexp.Compile(line)
+		if err != nil {
+			return nil, err
+		}
+		re.Longest()
+		p.re = re
+		m.pats = append(m.pats, p)
+	}
+	return &m, nil
+}
+
+// MustCompile is like Compile but panics if expr cannot be parsed.
+func MustCompile(expr string) *Regexp {
+	m, err := Compile(expr)
+	if err != nil {
+		panic(`funcname: Compile(` + expr + `): ` + err.Error())
+	}
+	return m
+}
+
+// Match implements Matcher.
+func (m *Regexp) Match(line string) (header string, ok bool) {
+	for _, p := range m.pats {
+		loc := p.re.FindStringSubmatchIndex(line)
+		if loc == nil {
+			continue
+		}
+		if p.negate {
+			return "", false
+		}
+		if len(loc) > 2 && loc[2] >= 0 {
+			loc = loc[2:]
+		}
+		return strings.TrimRight(line[loc[0]:loc[1]], " \t\n\v\f\r"), true
+	}
+	return "", false
+}
+
+// These Matchers are equivalent to git's built-in patterns of the same names.
+var (
+	// Default matches lines that begin with a letter, an underscore, or a dollar sign,
+	// as git does when no pattern has been configured.
+	Default = MustCompile(`^[A-Za-z_$].*`)
+
+	// C matches C and C++ functions, variables, and compound types at top level.
+	// It is git's "cpp" pattern.
+	C = MustCompile(
+		// jump targets or access declarations
+		`!^[ \t]*[A-Za-z_][A-Za-z_0-9]*:[[:space:]]*($|/[/*])` + "\n" +
+			// functions/methods, variables, and compounds at top level
+			`^((::[[:space:]]*)?[A-Za-z_].*)$`)
+
+	// Go matches Go functions, methods, and struct and interface types.
+	// It is git's "golang" pattern.
+	Go = MustCompile(
+		// functions
+		`^[ \t]*(func[ \t]*.*(\{[ \t]*)?)` + "\n" +
+			// structs and interfaces
+			`^[ \t]*(type[ \t].*(struct|interface)[ \t]*(\{[ \t]*)?)`)
+
+	// Java matches Java classes, enums, interfaces, records, and methods.
+	// It is git's "java" pattern.
+	Java = MustCompile(
+		`!^[ \t]*(catch|do|for|if|instanceof|new|return|switch|throw|while)` + "\n" +
+			// class, enum, interface, and record declarations
+			`^[ \t]*(([a-z-]+[ \t]+)*(class|enum|interface|record)[ \t]+.*)$` + "\n" +
+			// method definitions; constructors are indistinguishable from method calls
+			`^[ \t]*(([A-Za-z_<>&][][?&<>.,A-Za-z_0-9]*[ \t]+)+[A-Za-z_][A-Za-z_0-9]*[ \t]*\([^;]*)$`)
+
+	// Markdown matches ATX headings, such as "## Usage".
+	// It is git's "markdown" pattern.
+	Markdown = MustCompile(`^ {0,3}#{1,6}[ \t].*`)
+
+	// Python matches Python classes and functions.
+	// It is git's "python" pattern.
+	Python = MustCompile(`^[ \t]*((class|(async[ \t]+)?def)[ \t].*)$`)
+)
+
+// maxHeader is the maximum length in bytes of a header returned by Headers.
+// git uses the same limit.
+const maxHeader = 80
+
+// Headers returns a function that provides the section header
+// for a hunk of a diff that begins at a[ai],
+// suitable for use with write.SectionHeaders.
+//
+// The header is that of the nearest line before a[ai] matched by m,
+// truncated to 80 bytes, as in git.
+// If no line before a[ai] matches, the header is empty.
+// A trailing carriage return is removed from each line before it is matched.
+func Headers(a []string, m Matcher) func(ai int) string {
+	// Hunks are usually requested in order,
+	// so remember the previous result to avoid searching the same lines again.
+	prev, prevHeader := 0, ""
+	return func(ai int) string {
+		if ai > len(a) {
+			ai = len(a)
+		}
+		limit, header := 0, ""
+		if ai >= prev {
+			limit, header = prev, prevHeader
+		}
+		for i := ai - 1; i >= limit; i-- {
+			if h, ok := m.Match(strings.TrimSuffix(a[i], "\r")); ok {
+				header = truncate(h)
+				break
+			}
+		}
+		prev, prevHeader = ai, header
+		return header
+	}
+}
+
+// truncate shortens s to at most maxHeader bytes,
+// without splitting a UTF-8 encoded rune.
+func truncate(s string) string {
+	if len(s) <= maxHeader {
+		return s
+	}
+	n := maxHeader
+	for n > 0 && !utf8.RuneStart(s[n]) {
+		n--
+	}
+	return s[:n]
+}
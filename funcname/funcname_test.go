@@ -0,0 +1,128 @@
+package funcname_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/pkg/diff/funcname"
+)
+
+var matchTests = []struct {
+	name   string
+	m      funcname.Matcher
+	line   string
+	header string // empty means no match
+}{
+	{"Default", funcname.Default, "main() {", "main() {"},
+	{"Default", funcname.Default, "$x = 1;  ", "$x = 1;"},
+	{"Default", funcname.Default, "\tindented", ""},
+	{"Default", funcname.Default, "", ""},
+
+	{"C", funcname.C, "int main(int argc, char **argv)", "int main(int argc, char **argv)"},
+	{"C", funcname.C, "struct point {", "struct point {"},
+	{"C", funcname.C, "::operator new(size_t n)", "::operator new(size_t n)"},
+	{"C", funcname.C, "public:", ""},
+	{"C", funcname.C, "out: // cleanup", ""},
+	{"C", funcname.C, "\treturn 0;", ""},
+
+	{"Go", funcname.Go, "func main() {", "func main() {"},
+	{"Go", funcname.Go, "func (r *Range) Op() Op {", "func (r *Range) Op() Op {"},
+	{"Go", funcname.Go, "\tfunc() {", "func() {"},
+	{"Go", funcname.Go, "\tf := func(x int) {", ""},
+	{"Go", funcname.Go, "type Script struct {", "type Script struct {"},
+	{"Go", funcname.Go, "type Pair interface {", "type Pair interface {"},
+	{"Go", funcname.Go, "type Op int", ""},
+	{"Go", funcname.Go, "var x = 1", ""},
+
+	{"Java", funcname.Java, "public class Foo extends Bar {", "public class Foo extends Bar {"},
+	{"Java", funcname.Java, "  private static final record Point(int x, int y) {", "private static final record Point(int x, int y) {"},
+	{"Java", funcname.Java, "    public List<String> names(int n) throws IOException {", "public List<String> names(int n) throws IOException {"},
+	{"Java", funcname.Java, "    return foo(x);", ""},
+	{"Java", funcname.Java, "    if (x) {", ""},
+	{"Java", funcname.Java, "    foo(x);", ""},
+
+	{"Markdown", funcname.Markdown, "## Usage", "## Usage"},
+	{"Markdown", funcname.Markdown, "   # Title  ", "   # Title"},
+	{"Markdown", funcname.Markdown, "    # code", ""},
+	{"Markdown", funcname.Markdown, "#hashtag", ""},
+
+	{"Python", funcname.Python, "class Foo(Bar):", "class Foo(Bar):"},
+	{"Python", funcname.Python, "    def method(self):", "def method(self):"},
+	{"Python", funcname.Python, "async def main():", "async def main():"},
+	{"Python", funcname.Python, "    return define", ""},
+}
+
+func TestMatch(t *testing.T) {
+	for _, test := range matchTests {
+		header, ok := test.m.Match(test.line)
+		if header != test.header || ok != (test.header != "") {
+			t.Errorf("%s.Match(%q) = %q, %v, want %q", test.name, test.line, header, ok, test.header)
+		}
+	}
+}
+
+func TestCompile(t *testing.T) {
+	m, err := funcname.Compile("!^skip\n^(section) [0-9]+\n^s")
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		line   string
+		header string
+	}{
+		{"section 12", "section"}, // first subexpression
+		{"skip this", ""},         // negated
+		{"s  ", "s"},              // whole match, without trailing white space
+		{"other", ""},
+	}
+	for _, test := range tests {
+		if header, _ := m.Match(test.line); header != test.header {
+			t.Errorf("Match(%q) = %q, want %q", test.line, header, test.header)
+		}
+	}
+
+	for _, expr := range []string{"", "("} {
+		if _, err := funcname.Compile(expr); err == nil {
+			t.Errorf("Compile(%q) succeeded, want error", expr)
+		}
+	}
+}
+
+func TestHeaders(t *testing.T) {
+	a := []string{
+		"package p",
+		"",
+		"func A() {\r",
+		"\tx()",
+		"}",
+		"",
+		"func " + strings.Repeat("B", 100) + "() {",
+		"\ty()",
+		"}",
+	}
+	header := funcname.Headers(a, funcname.Go)
+	tests := []struct {
+		ai   int
+		want string
+	}{
+		{0, ""},
+		{2, ""},
+		{3, "func A() {"},
+		{6, "func A() {"},
+		{9, "func " + strings.Repeat("B", 75)},
+		{100, "func " + strings.Repeat("B", 75)},
+		{5, "func A() {"}, // out of order
+		{1, ""},
+	}
+	for _, test := range tests {
+		if got := header(test.ai); got != test.want {
+			t.Errorf("header(%d) = %q, want %q", test.ai, got, test.want)
+		}
+	}
+
+	// Truncation does not split runes.
+	a = []string{"func " + strings.Repeat("é", 50)}
+	if got := funcname.Headers(a, funcname.Go)(1); got != "func "+strings.Repeat("é", 37) {
+		t.Errorf("header = %q, want %d runes", got, 5+37)
+	}
+}
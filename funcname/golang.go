@@ -0,0 +1,113 @@
+package funcname
+
+import (
+	"bytes"
+	"go/ast"
+	"go/parser"
+	"go/printer"
+	"go/token"
+	"sort"
+	"strings"
+)
+
+// GoHeaders returns a function that provides the section header
+// for a hunk of a diff that begins at line a[ai] of the Go source file src,
+// suitable for use with write.SectionHeaders.
+// Lines are numbered from 0 and split at each newline, as diff.Text does.
+//
+// Unlike the Go Matcher, which sees only one line at a time,
+// GoHeaders parses src and labels each hunk with the top-level
+// function, method, or type declaration that encloses a[ai].
+// Function literals are not declarations,
+// so hunks within them are labeled with their enclosing function.
+// Each type in a parenthesized group is a separate declaration.
+// A hunk that begins outside every such declaration,
+// such as between two functions, has an empty header.
+// Headers are declaration signatures, without bodies or comments,
+// such as "func (r *Range) Op() Op", "type Script struct",
+// or "func Map[T, U any](xs []T, f func(T) U) []U".
+//
+// If src cannot be parsed, GoHeaders returns an error.
+func GoHeaders(src []byte) (func(ai int) string, error) {
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, "", src, 0)
+	if err != nil {
+		return nil, err
+	}
+	type decl struct {
+		line   int // first line, numbered from 0
+		end    int // last line
+		header string
+	}
+	var decls []decl
+	add := func(pos, end token.Pos, header string) {
+		decls = append(decls, decl{
+			line:   fset.Position(pos).Line - 1,
+			end:    fset.Position(end).Line - 1,
+			header: header,
+		})
+	}
+	for _, d := range f.Decls {
+		switch d := d.(type) {
+		case *ast.FuncDecl:
+			add(d.Pos(), d.End(), funcHeader(fset, d))
+		case *ast.GenDecl:
+			if d.Tok != token.TYPE {
+				continue
+			}
+			for _, spec := range d.Specs {
+				spec := spec.(*ast.TypeSpec)
+				pos, end := spec.Pos(), spec.End()
+				if !d.Lparen.IsValid() {
+					pos = d.Pos() // include the "type" keyword
+				}
+				add(pos, end, typeHeader(fset, spec))
+			}
+		}
+	}
+	return func(ai int) string {
+		// Find the last declaration that begins at or before a[ai].
+		// Declarations do not overlap, so it is the only one that can enclose a[ai].
+		i := sort.Search(len(decls), func(i int) bool { return decls[i].line > ai })
+		if i == 0 || decls[i-1].end < ai {
+			return ""
+		}
+		return decls[i-1].header
+	}, nil
+}
+
+// funcHeader returns the signature of d, including any type parameters,
+// such as "func (r *Range) Op() Op".
+func funcHeader(fset *token.FileSet, d *ast.FuncDecl) string {
+	return format(fset, &ast.FuncDecl{Recv: d.Recv, Name: d.Name, Type: d.Type})
+}
+
+// typeHeader returns the declaration of spec, including any type parameters,
+// but without the fields or methods of a struct or interface,
+// such as "type Script struct" or "type Op int".
+func typeHeader(fset *token.FileSet, spec *ast.TypeSpec) string {
+	// Copy spec, rather than building a new TypeSpec,
+	// to keep its type parameters.
+	t := *spec
+	t.Doc, t.Comment = nil, nil
+	switch spec.Type.(type) {
+	case *ast.StructType:
+		t.Type = ast.NewIdent("struct")
+	case *ast.InterfaceType:
+		t.Type = ast.NewIdent("interface")
+	}
+	return format(fset, &ast.GenDecl{Tok: token.TYPE, Specs: []ast.Spec{&t}})
+}
+
+// format prints node on a single line,
+// joining any lines it spans in the source.
+func format(fset *token.FileSet, node interface{}) string {
+	var buf bytes.Buffer
+	if err := printer.Fprint(&buf, fset, node); err != nil {
+		return ""
+	}
+	s := strings.Join(strings.Fields(buf.String()), " ")
+	// Remove the spaces and trailing commas left by joining lines within brackets.
+	s = strings.NewReplacer("( ", "(", " )", ")", "[ ", "[", " ]", "]").Replace(s)
+	return strings.NewReplacer(",)", ")", ",]", "]").Replace(s)
+}
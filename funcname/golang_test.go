@@ -0,0 +1,93 @@
+package funcname_test
+
+import (
+	"testing"
+
+	"github.com/pkg/diff/funcname"
+)
+
+const goSrc = `package p
+
+import "fmt"
+
+// A Range is a range.
+type Range struct {
+	Low, High int
+}
+
+type (
+	Op    int
+	Alias = Range
+	Pair  interface {
+		Len() int
+	}
+)
+
+func (r *Range) Op() Op {
+	f := func() {
+		fmt.Println(r)
+	}
+	f()
+	return 0
+}
+
+func split(s []string,
+	n int) (head, tail []string, err error) {
+	return s[:n], s[n:], nil
+}
+
+var x = 1
+
+func Map[T, U any](xs []T, f func(T) U) []U {
+	return nil
+}
+
+type List[T any] struct {
+	next *List[T]
+}
+
+func (l *List[T]) Push(v T) {
+}
+`
+
+func TestGoHeaders(t *testing.T) {
+	header, err := funcname.GoHeaders([]byte(goSrc))
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		ai   int
+		want string
+	}{
+		{0, ""},
+		{4, ""},
+		{5, "type Range struct"},
+		{7, "type Range struct"},
+		{8, ""},
+		{9, ""},
+		{10, "type Op int"},
+		{11, "type Alias = Range"},
+		{13, "type Pair interface"},
+		{14, "type Pair interface"},
+		{15, ""},
+		{17, "func (r *Range) Op() Op"},
+		{19, "func (r *Range) Op() Op"}, // within a function literal
+		{23, "func (r *Range) Op() Op"},
+		{26, "func split(s []string, n int) (head, tail []string, err error)"},
+		{28, "func split(s []string, n int) (head, tail []string, err error)"},
+		{29, ""},
+		{30, ""},
+		{33, "func Map[T, U any](xs []T, f func(T) U) []U"},
+		{37, "type List[T any] struct"},
+		{40, "func (l *List[T]) Push(v T)"},
+	}
+	for _, test := range tests {
+		if got := header(test.ai); got != test.want {
+			t.Errorf("header(%d) = %q, want %q", test.ai, got, test.want)
+		}
+	}
+
+	if _, err := funcname.GoHeaders([]byte("package p\nfunc {")); err == nil {
+		t.Errorf("GoHeaders succeeded on invalid source, want error")
+	}
+}
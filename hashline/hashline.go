@@ -0,0 +1,241 @@
+// Package hashline identifies lines by their hashes.
+//
+// Diff algorithms compare lines many times.
+// Rather than keeping the text of every line around to compare,
+// or interning it in a map as package intern does,
+// this package assigns each line a small integer id
+// derived from its 64-bit hash, so that comparing lines
+// is an integer comparison. Lines whose hashes collide are
+// compared byte by byte when their ids are assigned,
+// so ids are exact: lines are equal exactly when their ids are equal.
+//
+// Line contents are read back only to resolve hash collisions
+// and to write output.
+package hashline
+
+import (
+	"bytes"
+	"hash"
+	"hash/maphash"
+	"io"
+)
+
+// Lines is a sequence of lines whose contents can be read on demand.
+type Lines interface {
+	// Len returns the number of lines.
+	Len() int
+	// AppendLine appends the contents of line i to dst
+	// and returns the extended buffer.
+	AppendLine(dst []byte, i int) ([]byte, error)
+}
+
+// A Table assigns ids to lines.
+// The zero value is not usable; use NewTable.
+type Table struct {
+	seed maphash.Seed
+	head map[uint64]int // first id with a given hash
+	next []int          // next[id] is the next id with the same hash, or -1
+	rep  []loc          // rep[id] is the first line with that id
+	bufs [2][]byte
+}
+
+// A loc is the location of a line.
+type loc struct {
+	l Lines
+	i int
+}
+
+// NewTable returns a new, empty Table.
+func NewTable() *Table {
+	return &Table{seed: maphash.MakeSeed(), head: make(map[uint64]int)}
+}
+
+// NewHash returns a hash that computes the sums used by t.
+// It is useful for hashing lines incrementally, as they are read.
+func (t *Table) NewHash() hash.Hash64 {
+	h := new(maphash.Hash)
+	h.SetSeed(t.seed)
+	return h
+}
+
+// Sum returns the hash of line used by t.
+func (t *Table) Sum(line []byte) uint64 {
+	h := t.NewHash()
+	h.Write(line)
+	return h.Sum64()
+}
+
+// Len returns the number of distinct ids that t has assigned.
+func (t *Table) Len() int {
+	return len(t.rep)
+}
+
+// ID returns the id of line i of l, whose hash is sum.
+// It assigns a new id if no line with the same contents has been seen before.
+// If the hash of line i collides with that of another line,
+// ID reads both lines to compare them.
+// It returns an error only if reading a line fails.
+func (t *Table) ID(sum uint64, l Lines, i int) (int, error) {
+	id, ok := t.head[sum]
+	if !ok {
+		id = -1
+	}
+	last := -1
+	for ; id != -1; last, id = id, t.next[id] {
+		eq, err := t.equal(t.rep[id], loc{l, i})
+		if err != nil {
+			return 0, err
+		}
+		if eq {
+			return id, nil
+		}
+	}
+	id = len(t.rep)
+	t.rep = append(t.rep, loc{l, i})
+	t.next = append(t.next, -1)
+	if last == -1 {
+		t.head[sum] = id
+	} else {
+		t.next[last] = id
+	}
+	return id, nil
+}
+
+// equal reports whether the lines at x and y have the same contents.
+func (t *Table) equal(x, y loc) (bool, error) {
+	xl, err := x.l.AppendLine(t.bufs[0][:0], x.i)
+	if err != nil {
+		return false, err
+	}
+	t.bufs[0] = xl
+	yl, err := y.l.AppendLine(t.bufs[1][:0], y.i)
+	if err != nil {
+		return false, err
+	}
+	t.bufs[1] = yl
+	return bytes.Equal(xl, yl), nil
+}
+
+// A Pair is a pair of Lines whose lines are identified by hash.
+// It implements myers.Pair and write.NewlinePair.
+// It also provides the KeyA and KeyB methods used
+// by the patience and histogram packages.
+//
+// If a Lines also has a method
+//
+//	NoNewline(i int) bool
+//
+// reporting whether line i lacks a trailing newline, as the Lines returned by Text do,
+// such a line is not equal to the same line with a trailing newline,
+// and the Pair reports it from NoNewlineA or NoNewlineB.
+type Pair struct {
+	a, b Lines
+	// ida[i] and idb[i] identify the contents of line i of a and b.
+	// A line that lacks a trailing newline has a negative id,
+	// the bitwise complement of the id of its contents,
+	// so that it is not equal to any line that has a trailing newline.
+	ida, idb []int
+}
+
+// A newlineLines is a Lines that knows which lines lack a trailing newline.
+type newlineLines interface {
+	Lines
+	NoNewline(i int) bool
+}
+
+// NewPair reads and hashes every line of a and b,
+// and returns a Pair for them.
+func NewPair(a, b Lines) (*Pair, error) {
+	t := NewTable()
+	ab := &Pair{a: a, b: b}
+	var err error
+	if ab.ida, err = ids(t, a); err != nil {
+		return nil, err
+	}
+	if ab.idb, err = ids(t, b); err != nil {
+		return nil, err
+	}
+	return ab, nil
+}
+
+// ids returns the ids of all of the lines in l.
+func ids(t *Table, l Lines) ([]int, error) {
+	x := make([]int, l.Len())
+	nl, _ := l.(newlineLines)
+	var buf []byte
+	for i := range x {
+		var err error
+		buf, err = l.AppendLine(buf[:0], i)
+		if err != nil {
+			return nil, err
+		}
+		if x[i], err = t.ID(t.Sum(buf), l, i); err != nil {
+			return nil, err
+		}
+		if nl != nil && nl.NoNewline(i) {
+			x[i] = ^x[i]
+		}
+	}
+	return x, nil
+}
+
+func (ab *Pair) LenA() int                                 { return len(ab.ida) }
+func (ab *Pair) LenB() int                                 { return len(ab.idb) }
+func (ab *Pair) Equal(ai, bi int) bool                     { return ab.ida[ai] == ab.idb[bi] }
+func (ab *Pair) KeyA(ai int) interface{}                   { return ab.ida[ai] }
+func (ab *Pair) KeyB(bi int) interface{}                   { return ab.idb[bi] }
+func (ab *Pair) NoNewlineA(ai int) bool                    { return ab.ida[ai] < 0 }
+func (ab *Pair) NoNewlineB(bi int) bool                    { return ab.idb[bi] < 0 }
+func (ab *Pair) WriteATo(w io.Writer, ai int) (int, error) { return writeLine(w, ab.a, ai) }
+func (ab *Pair) WriteBTo(w io.Writer, bi int) (int, error) { return writeLine(w, ab.b, bi) }
+
+func writeLine(w io.Writer, l Lines, i int) (int, error) {
+	line, err := l.AppendLine(nil, i)
+	if err != nil {
+		return 0, err
+	}
+	return w.Write(line)
+}
+
+// Text returns the lines of data, split at each newline.
+// The newline is not part of the line, but any other line terminator,
+// such as the carriage return of a "\r\n", is.
+// Only the offsets of the lines are stored, not copies of them.
+// The Lines also have a NoNewline method, for use with Pair.
+func Text(data []byte) Lines {
+	t := &text{data: data, off: []int{0}}
+	for len(data) > 0 {
+		n := bytes.IndexByte(data, '\n') + 1
+		if n == 0 {
+			n = len(data)
+			t.noNewline = true
+		}
+		t.off = append(t.off, t.off[len(t.off)-1]+n)
+		data = data[n:]
+	}
+	return t
+}
+
+// A text is the lines of a byte slice.
+type text struct {
+	data []byte
+	off  []int // off[i] is the offset of line i; the last element is len(data)
+	// noNewline reports whether the last line lacks a trailing newline.
+	noNewline bool
+}
+
+// NoNewline reports whether line i lacks a trailing newline,
+// which only the last line can.
+func (t *text) NoNewline(i int) bool {
+	return t.noNewline && i == t.Len()-1
+}
+
+func (t *text) Len() int {
+	return len(t.off) - 1
+}
+
+func (t *text) AppendLine(dst []byte, i int) ([]byte, error) {
+	line := t.data[t.off[i]:t.off[i+1]]
+	line = bytes.TrimSuffix(line, []byte{'\n'})
+	return append(dst, line...), nil
+}
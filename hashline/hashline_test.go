@@ -0,0 +1,115 @@
+package hashline_test
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/pkg/diff/ctxt"
+	"github.com/pkg/diff/hashline"
+	"github.com/pkg/diff/myers"
+	"github.com/pkg/diff/write"
+)
+
+func TestCollision(t *testing.T) {
+	l := hashline.Text([]byte("a\nb\na\nb\n"))
+	tab := hashline.NewTable()
+	// Pretend that every line has the same hash.
+	const sum = 42
+	var ids []int
+	for i := 0; i < l.Len(); i++ {
+		id, err := tab.ID(sum, l, i)
+		if err != nil {
+			t.Fatal(err)
+		}
+		ids = append(ids, id)
+	}
+	if ids[0] == ids[1] || ids[0] != ids[2] || ids[1] != ids[3] {
+		t.Errorf("got ids %v, want [x y x y]", ids)
+	}
+	if tab.Len() != 2 {
+		t.Errorf("got %d distinct ids, want 2", tab.Len())
+	}
+}
+
+func TestText(t *testing.T) {
+	tests := []struct {
+		text string
+		want []string
+	}{
+		{text: "", want: nil},
+		{text: "\n", want: []string{""}},
+		{text: "a\nb", want: []string{"a", "b"}},
+		{text: "a\r\nb\r\n", want: []string{"a\r", "b\r"}},
+		{text: "a\rb\n", want: []string{"a\rb"}},
+	}
+	for _, test := range tests {
+		l := hashline.Text([]byte(test.text))
+		if l.Len() != len(test.want) {
+			t.Errorf("Text(%q) has %d lines, want %d", test.text, l.Len(), len(test.want))
+			continue
+		}
+		for i, want := range test.want {
+			got, err := l.AppendLine([]byte("x"), i)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(got) != "x"+want {
+				t.Errorf("Text(%q).AppendLine(%q, %d) = %q, want %q", test.text, "x", i, got, "x"+want)
+			}
+		}
+	}
+}
+
+func TestPair(t *testing.T) {
+	tests := []struct {
+		name string
+		a, b string
+		want string
+	}{
+		{
+			name: "Simple",
+			a:    "a\nb\nc\n",
+			b:    "a\nc\nd\n",
+			want: `
+--- a
++++ b
+@@ -1,3 +1,3 @@
+ a
+-b
+ c
++d
+`[1:],
+		},
+		{
+			name: "NoNewline",
+			a:    "a\nb",
+			b:    "a\nb\n",
+			want: `
+--- a
++++ b
+@@ -1,2 +1,2 @@
+ a
+-b
+\ No newline at end of file
++b
+`[1:],
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ab, err := hashline.NewPair(hashline.Text([]byte(test.a)), hashline.Text([]byte(test.b)))
+			if err != nil {
+				t.Fatal(err)
+			}
+			e := myers.Diff(context.Background(), ab)
+			buf := new(bytes.Buffer)
+			if err := write.Unified(ctxt.Size(e, 3), buf, ab); err != nil {
+				t.Fatal(err)
+			}
+			if got := buf.String(); got != test.want {
+				t.Errorf("bad diff:\ngot:\n%s\nwant:\n%s", got, test.want)
+			}
+		})
+	}
+}
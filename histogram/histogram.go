@@ -0,0 +1,253 @@
+// Package histogram implements the histogram diff algorithm,
+// as used by git diff --histogram.
+//
+// Histogram diff is an extension of patience diff.
+// Instead of anchoring the diff only on elements that occur exactly once,
+// it anchors it on the longest common region whose elements
+// occur least often in A, and then recursively diffs the regions
+// before and after it.
+package histogram
+
+import (
+	"context"
+
+	"github.com/pkg/diff/edit"
+	"github.com/pkg/diff/internal/build"
+	"github.com/pkg/diff/internal/pairs"
+	"github.com/pkg/diff/myers"
+)
+
+// maxChain is the number of occurrences in A beyond which
+// an element is no longer considered as an anchor.
+// If every common element occurs more often than this,
+// Diff falls back to myers.Diff. It matches git's limit.
+const maxChain = 64
+
+// Diff calculates an edit.Script for ab using the histogram diff algorithm.
+// Regions of ab in which all common elements are too frequent
+// to be useful as anchors are diffed using myers.Diff.
+//
+// Histogram diff needs to know which elements of A are equal to each other.
+// If ab has methods
+//
+//	KeyA(ai int) interface{}
+//	KeyB(bi int) interface{}
+//
+// returning comparable values that are equal exactly when the elements are equal,
+// Diff uses them to group elements in linear time.
+// Otherwise, grouping elements would take quadratic time,
+// so Diff uses myers.Diff instead.
+//
+// With keys, Diff then slides each run of changes over equal elements
+// as git does, to line it up with changes in the other side where possible.
+// The result matches git diff --histogram --no-indent-heuristic.
+// git's default indent heuristic, which chooses among positions
+// by the indentation of the surrounding lines, is not applied,
+// because Diff does not see the text of the elements.
+//
+// Because diff calculation can be expensive, Diff supports cancellation via ctx.
+// If ctx is cancelled, Diff returns an empty edit.Script.
+func Diff(ctx context.Context, ab myers.Pair) edit.Script {
+	d := &differ{ctx: ctx, ab: ab}
+	if k, keyed := ab.(pairs.Keyer); keyed {
+		d.classA, d.classB = pairs.Classes(ab.LenA(), ab.LenB(), k)
+		if !d.diff(0, ab.LenA(), 0, ab.LenB()) {
+			return edit.Script{}
+		}
+		return pairs.Compact(pairs.Script(&d.s), ab.LenA(), ab.LenB(), k)
+	}
+	if !d.fallback(0, ab.LenA(), 0, ab.LenB()) {
+		return edit.Script{}
+	}
+	return pairs.Script(&d.s)
+}
+
+// A differ holds the state for a single histogram diff.
+type differ struct {
+	ctx            context.Context
+	ab             myers.Pair
+	classA, classB []int
+	s              build.Builder
+}
+
+// diff diffs A[lowA:highA] against B[lowB:highB], appending the result to d.s.
+// It reports whether it completed; it returns false if d.ctx was cancelled.
+func (d *differ) diff(lowA, highA, lowB, highB int) bool {
+	if d.ctx != nil && d.ctx.Err() != nil {
+		return false
+	}
+	switch {
+	case lowA == highA:
+		d.s.Ins(highB - lowB)
+		return true
+	case lowB == highB:
+		d.s.Del(highA - lowA)
+		return true
+	}
+
+	x := newIndex(d, lowA, highA, lowB, highB)
+	lcs, ok := x.findLCS()
+	switch {
+	case !ok:
+		return d.fallback(lowA, highA, lowB, highB)
+	case lcs.highA == lcs.lowA:
+		// Nothing in common.
+		d.s.Del(highA - lowA)
+		d.s.Ins(highB - lowB)
+		return true
+	}
+	if !d.diff(lowA, lcs.lowA, lowB, lcs.lowB) {
+		return false
+	}
+	d.s.Eq(lcs.highA - lcs.lowA)
+	return d.diff(lcs.highA, highA, lcs.highB, highB)
+}
+
+// fallback diffs A[lowA:highA] against B[lowB:highB] using myers.Diff.
+func (d *differ) fallback(lowA, highA, lowB, highB int) bool {
+	e, err := myers.DiffErr(d.ctx, pairs.Sub(d.ab, lowA, highA, lowB, highB))
+	if err != nil {
+		return false
+	}
+	for _, r := range e.Ranges {
+		pairs.Add(&d.s, r.Op(), r.Len())
+	}
+	return true
+}
+
+// A region is a pair of equal ranges, A[lowA:highA] and B[lowB:highB].
+type region struct {
+	lowA, highA int
+	lowB, highB int
+}
+
+// A record describes all occurrences of an element in the indexed portion of A.
+type record struct {
+	cnt int // number of occurrences
+	ptr int // index in A of the first occurrence
+}
+
+// An index is a histogram of the elements of A[lowA:highA].
+type index struct {
+	d          *differ
+	lowA, lowB int
+	highA      int
+	highB      int
+	records    map[int]*record
+	next       []int // next[ai-lowA] is the index of the next occurrence of A[ai], or -1
+	cnt        int   // lowest occurrence count found so far
+	hasCommon  bool  // whether any common elements were found
+	lcs        region
+}
+
+func newIndex(d *differ, lowA, highA, lowB, highB int) *index {
+	x := &index{
+		d:    d,
+		lowA: lowA, highA: highA,
+		lowB: lowB, highB: highB,
+		records: make(map[int]*record),
+		next:    make([]int, highA-lowA),
+		cnt:     maxChain + 1,
+	}
+	for ai := highA - 1; ai >= lowA; ai-- {
+		x.next[ai-lowA] = -1
+		c := d.classA[ai]
+		if c < 0 {
+			continue
+		}
+		rec := x.records[c]
+		if rec == nil {
+			x.records[c] = &record{cnt: 1, ptr: ai}
+			continue
+		}
+		x.next[ai-lowA] = rec.ptr
+		rec.ptr = ai
+		rec.cnt++
+	}
+	return x
+}
+
+// count returns the number of occurrences of A[ai] in the indexed portion of A.
+func (x *index) count(ai int) int {
+	return x.records[x.d.classA[ai]].cnt
+}
+
+// findLCS finds the longest common region whose elements occur least often in A.
+// If there are no common elements, it returns an empty region.
+// It reports false if the common elements are all too frequent,
+// in which case the caller should fall back to another algorithm.
+func (x *index) findLCS() (region, bool) {
+	for bi := x.lowB; bi < x.highB; {
+		bi = x.tryLCS(bi)
+	}
+	if x.hasCommon && maxChain < x.cnt {
+		return region{}, false
+	}
+	return x.lcs, true
+}
+
+// tryLCS looks for common regions that include B[bi],
+// updating x.lcs if any is better than the best found so far.
+// It returns the next index of B to try.
+func (x *index) tryLCS(bi int) int {
+	bNext := bi + 1
+	c := x.d.classB[bi]
+	if c < 0 {
+		return bNext
+	}
+	rec := x.records[c]
+	if rec == nil {
+		return bNext
+	}
+	x.hasCommon = true
+	if rec.cnt > x.cnt {
+		return bNext
+	}
+
+	for as := rec.ptr; ; {
+		np := x.next[as-x.lowA]
+		bs := bi
+		ae, be := as, bs // inclusive
+		rc := rec.cnt
+
+		for x.lowA < as && x.lowB < bs && x.d.ab.Equal(as-1, bs-1) {
+			as--
+			bs--
+			if rc > 1 {
+				rc = min(rc, x.count(as))
+			}
+		}
+		for ae+1 < x.highA && be+1 < x.highB && x.d.ab.Equal(ae+1, be+1) {
+			ae++
+			be++
+			if rc > 1 {
+				rc = min(rc, x.count(ae))
+			}
+		}
+
+		if bNext <= be {
+			bNext = be + 1
+		}
+		if x.lcs.highA-x.lcs.lowA < ae-as+1 || rc < x.cnt {
+			x.lcs = region{lowA: as, highA: ae + 1, lowB: bs, highB: be + 1}
+			x.cnt = rc
+		}
+
+		// Skip occurrences that are already part of this region.
+		for np != -1 && np <= ae {
+			np = x.next[np-x.lowA]
+		}
+		if np == -1 {
+			break
+		}
+		as = np
+	}
+	return bNext
+}
+
+func min(x, y int) int {
+	if x < y {
+		return x
+	}
+	return y
+}
@@ -0,0 +1,242 @@
+package histogram_test
+
+import (
+	"bytes"
+	"context"
+	"math/rand"
+	"strings"
+	"testing"
+
+	"github.com/pkg/diff/ctxt"
+	"github.com/pkg/diff/funcname"
+	"github.com/pkg/diff/histogram"
+	"github.com/pkg/diff/internal/difftest"
+	"github.com/pkg/diff/myers"
+	"github.com/pkg/diff/write"
+)
+
+var goldenTests = []struct {
+	name string
+	a, b string
+	want string // from git diff --no-index --no-prefix --histogram a b
+}{
+	{
+		name: "RareAnchor",
+		a:    "a\nb\nc\na\nb\nc\nd",
+		b:    "a\nb\nx\nc\nd\na\nb\nc",
+		want: `
+--- a
++++ b
+@@ -1,7 +1,8 @@
+ a
+ b
+-c
+-a
+-b
++x
+ c
+ d
++a
++b
++c
+`[1:],
+	},
+	{
+		name: "Slide1",
+		a:    "a\nc\nd\nd\nb\nb\nd\nb\nb\nd\na\nb",
+		b:    "a\nd\nd\nb\nb\nd\na\nb",
+		want: `
+--- a
++++ b
+@@ -1,12 +1,8 @@
+ a
+-c
+ d
+ d
+ b
+ b
+ d
+-b
+-b
+-d
+ a
+ b
+`[1:],
+	},
+	{
+		name: "Slide2",
+		a:    "d\nb\nd\nb\nc\na\nb\nd\nd\nb\nd",
+		b:    "d\nb\na\nb\nd\nb\nc\na\nb\nb\nd",
+		want: `
+--- a
++++ b
+@@ -1,11 +1,11 @@
+ d
+ b
++a
++b
+ d
+ b
+ c
+ a
+ b
+-d
+-d
+ b
+ d
+`[1:],
+	},
+	{
+		name: "Slide3",
+		a:    "b\nb\nc\nc\nb\nd\na\nd\nd\na\nc\nd\nb",
+		b:    "b\nb\nc\nc\nb\nd\nd\nc\nb\na\nc\nd\nb",
+		want: `
+--- a
++++ b
+@@ -4,9 +4,9 @@ c
+ c
+ b
+ d
+-a
+-d
+ d
++c
++b
+ a
+ c
+ d
+`[1:],
+	},
+	{
+		name: "Slide4",
+		a:    "d\nd\nd\nb\nd\nd\na\nb",
+		b:    "d\nb\nd\nd\nd\nb\nd\nd\na\nb\nb\nb",
+		want: `
+--- a
++++ b
+@@ -1,4 +1,6 @@
+ d
++b
++d
+ d
+ d
+ b
+@@ -6,3 +8,5 @@ d
+ d
+ a
+ b
++b
++b
+`[1:],
+	},
+	{
+		name: "Slide5",
+		a:    "d\na\na\na\na\nc\na\nb\nd\nc\na",
+		b:    "d\nb\nb\na\na\na\na\nc\na\nb\nd\nc\nb\na\na",
+		want: `
+--- a
++++ b
+@@ -1,4 +1,6 @@
+ d
++b
++b
+ a
+ a
+ a
+@@ -8,4 +10,6 @@ a
+ b
+ d
+ c
++b
++a
+ a
+`[1:],
+	},
+	{
+		name: "Slide6",
+		a:    "c\na\nb\nb\nb\nb\na\nd\nd\nb",
+		b:    "c\na\nd\nb\nb\nb\nb\nb\nd\nb",
+		want: `
+--- a
++++ b
+@@ -1,10 +1,10 @@
+ c
+ a
+-b
+-b
+-b
+-b
+-a
+-d
++d
++b
++b
++b
++b
++b
+ d
+ b
+`[1:],
+	},
+}
+
+func TestGolden(t *testing.T) {
+	for _, test := range goldenTests {
+		t.Run(test.name, func(t *testing.T) {
+			ab := &difftest.Strings{A: strings.Split(test.a, "\n"), B: strings.Split(test.b, "\n")}
+			e := histogram.Diff(context.Background(), ab)
+			e = ctxt.Size(e, 3)
+			buf := new(bytes.Buffer)
+			// git adds section headers, using its default pattern.
+			headers := write.SectionHeaders(funcname.Headers(ab.A, funcname.Default))
+			if err := write.Unified(e, buf, ab, headers); err != nil {
+				t.Fatal(err)
+			}
+			if got := buf.String(); got != test.want {
+				t.Errorf("bad diff:\ngot:\n%s\nwant:\n%s", got, test.want)
+			}
+		})
+	}
+}
+
+func TestHistogramRandom(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	for i := 0; i < 1000; i++ {
+		a := difftest.RandString(rng, rng.Intn(20), "ABCDEFGH")
+		b := difftest.RandString(rng, rng.Intn(20), "ABCDEFGH")
+		// Check both the keyed and unkeyed code paths.
+		for _, ab := range []myers.Pair{&difftest.Bytes{A: a, B: b}, &difftest.KeyedBytes{Bytes: difftest.Bytes{A: a, B: b}}} {
+			e := histogram.Diff(context.Background(), ab)
+			if err := difftest.CheckScript(a, b, e); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+}
+
+func TestHistogramCancel(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	e := histogram.Diff(ctx, &difftest.Bytes{A: "ABCABBA", B: "CBABAC"})
+	if len(e.Ranges) != 0 {
+		t.Errorf("got %v after cancellation, want empty script", e)
+	}
+}
+
+func TestHistogramUnkeyed(t *testing.T) {
+	// Without keys, Diff uses myers.Diff rather than
+	// comparing every element of A with every element of B.
+	ab := new(difftest.Counting)
+	for i := 0; i < 2000; i++ {
+		ab.A = append(ab.A, i)
+		ab.B = append(ab.B, i)
+	}
+	ab.B[1000] = -1
+	e := histogram.Diff(context.Background(), ab)
+	if ins, del := e.Stat(); ins != 1 || del != 1 {
+		t.Errorf("got %d insertions and %d deletions, want 1 and 1", ins, del)
+	}
+	if max := 10 * (len(ab.A) + len(ab.B)); ab.N > max {
+		t.Errorf("Diff made %d comparisons, want at most %d", ab.N, max)
+	}
+}
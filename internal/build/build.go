@@ -0,0 +1,122 @@
+// Package build builds edit scripts, operation by operation.
+//
+// It is shared by package edit and the diff algorithms.
+// Because package edit uses it, it does not refer to edit's types;
+// a Range has the same positions as an edit.Range.
+package build
+
+// A Range is a range of an edit script:
+// A[LowA:HighA] and B[LowB:HighB].
+// It is an insertion if its A range is empty,
+// a deletion if its B range is empty,
+// and a series of equal elements otherwise.
+type Range struct {
+	LowA, HighA int
+	LowB, HighB int
+}
+
+func (r Range) isEqual() bool  { return r.LowA != r.HighA && r.LowB != r.HighB }
+func (r Range) isInsert() bool { return r.LowA == r.HighA }
+func (r Range) isDelete() bool { return r.LowB == r.HighB }
+
+// A Builder builds an edit script, operation by operation,
+// in order from the start of A and B.
+// Adjacent operations of the same kind are combined,
+// and deletions are placed before any insertions they follow,
+// so that the script has the same shape as the output of myers.Diff.
+//
+// The zero value is an empty Builder ready to use.
+type Builder struct {
+	ranges []Range
+	x, y   int  // current position in A and B
+	gap    bool // whether the next range is discontiguous with the last
+}
+
+// last returns the last range, if the next range will be contiguous with it.
+func (s *Builder) last() *Range {
+	if len(s.ranges) == 0 || s.gap {
+		return nil
+	}
+	return &s.ranges[len(s.ranges)-1]
+}
+
+// Eq appends n equal elements.
+func (s *Builder) Eq(n int) {
+	if n == 0 {
+		return
+	}
+	if last := s.last(); last != nil && last.isEqual() {
+		last.HighA += n
+		last.HighB += n
+	} else {
+		s.ranges = append(s.ranges, Range{LowA: s.x, HighA: s.x + n, LowB: s.y, HighB: s.y + n})
+	}
+	s.gap = false
+	s.x += n
+	s.y += n
+}
+
+// Del appends a deletion of n elements.
+func (s *Builder) Del(n int) {
+	if n == 0 {
+		return
+	}
+	last := s.last()
+	switch {
+	case last != nil && last.isDelete():
+		last.HighA += n
+	case last != nil && last.isInsert():
+		// Move the deletion before the insertion,
+		// merging it with any deletion that immediately precedes the insertion.
+		ins := *last
+		if prev := len(s.ranges) - 2; prev >= 0 && s.ranges[prev].isDelete() &&
+			s.ranges[prev].HighA == ins.LowA && s.ranges[prev].HighB == ins.LowB {
+			s.ranges[prev].HighA += n
+			s.ranges = s.ranges[:len(s.ranges)-1]
+		} else {
+			s.ranges[len(s.ranges)-1] = Range{LowA: s.x, HighA: s.x + n, LowB: ins.LowB, HighB: ins.LowB}
+		}
+		ins.LowA += n
+		ins.HighA += n
+		s.ranges = append(s.ranges, ins)
+	default:
+		s.ranges = append(s.ranges, Range{LowA: s.x, HighA: s.x + n, LowB: s.y, HighB: s.y})
+	}
+	s.gap = false
+	s.x += n
+}
+
+// Ins appends an insertion of n elements.
+func (s *Builder) Ins(n int) {
+	if n == 0 {
+		return
+	}
+	if last := s.last(); last != nil && last.isInsert() {
+		last.HighB += n
+	} else {
+		s.ranges = append(s.ranges, Range{LowA: s.x, HighA: s.x, LowB: s.y, HighB: s.y + n})
+	}
+	s.gap = false
+	s.y += n
+}
+
+// Skip skips n equal elements, which are not recorded in the script,
+// as in an edit script whose context has been reduced by ctxt.Size.
+func (s *Builder) Skip(n int) {
+	if n == 0 {
+		return
+	}
+	s.x += n
+	s.y += n
+	s.gap = true
+}
+
+// Pos returns the number of elements of A and B added or skipped so far.
+func (s *Builder) Pos() (a, b int) {
+	return s.x, s.y
+}
+
+// Ranges returns the ranges of the edit script built so far.
+func (s *Builder) Ranges() []Range {
+	return s.ranges
+}
@@ -0,0 +1,49 @@
+package build_test
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/pkg/diff/internal/build"
+)
+
+func TestBuilder(t *testing.T) {
+	var s build.Builder
+	s.Eq(1)
+	s.Ins(2)
+	s.Del(1) // moved before the insertion
+	s.Eq(1)
+	s.Eq(1) // merged with the previous range
+	s.Skip(3)
+	s.Eq(1) // not merged across the gap
+	s.Del(0)
+	want := []build.Range{
+		{LowA: 0, HighA: 1, LowB: 0, HighB: 1},
+		{LowA: 1, HighA: 2, LowB: 1, HighB: 1},
+		{LowA: 2, HighA: 2, LowB: 1, HighB: 3},
+		{LowA: 2, HighA: 4, LowB: 3, HighB: 5},
+		{LowA: 7, HighA: 8, LowB: 8, HighB: 9},
+	}
+	if got := s.Ranges(); !reflect.DeepEqual(got, want) {
+		t.Errorf("Ranges() = %v, want %v", got, want)
+	}
+	if a, b := s.Pos(); a != 8 || b != 9 {
+		t.Errorf("Pos() = %d, %d, want 8, 9", a, b)
+	}
+
+	// A deletion after an insertion that follows a gap
+	// is not merged with the deletion before the gap.
+	s = build.Builder{}
+	s.Del(1)
+	s.Skip(2)
+	s.Ins(1)
+	s.Del(1)
+	want = []build.Range{
+		{LowA: 0, HighA: 1, LowB: 0, HighB: 0},
+		{LowA: 3, HighA: 4, LowB: 2, HighB: 2},
+		{LowA: 4, HighA: 4, LowB: 2, HighB: 3},
+	}
+	if got := s.Ranges(); !reflect.DeepEqual(got, want) {
+		t.Errorf("Ranges() = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,106 @@
+// Package difftest provides helpers for testing diff algorithms.
+package difftest
+
+import (
+	"fmt"
+	"io"
+	"math/rand"
+
+	"github.com/pkg/diff/edit"
+)
+
+// Bytes is a pair of strings diffed byte by byte.
+// It implements myers.Pair.
+type Bytes struct {
+	A, B string
+}
+
+func (ab *Bytes) LenA() int             { return len(ab.A) }
+func (ab *Bytes) LenB() int             { return len(ab.B) }
+func (ab *Bytes) Equal(ai, bi int) bool { return ab.A[ai] == ab.B[bi] }
+
+// KeyedBytes is a Bytes that also provides the KeyA and KeyB methods
+// used by the patience and histogram packages.
+type KeyedBytes struct {
+	Bytes
+}
+
+func (ab *KeyedBytes) KeyA(ai int) interface{} { return ab.A[ai] }
+func (ab *KeyedBytes) KeyB(bi int) interface{} { return ab.B[bi] }
+
+// Strings is a pair of string slices diffed element by element.
+// It implements myers.Pair and write.Pair,
+// and provides the KeyA and KeyB methods.
+type Strings struct {
+	A, B []string
+}
+
+func (ab *Strings) LenA() int                                { return len(ab.A) }
+func (ab *Strings) LenB() int                                { return len(ab.B) }
+func (ab *Strings) Equal(ai, bi int) bool                    { return ab.A[ai] == ab.B[bi] }
+func (ab *Strings) KeyA(ai int) interface{}                  { return ab.A[ai] }
+func (ab *Strings) KeyB(bi int) interface{}                  { return ab.B[bi] }
+func (ab *Strings) WriteATo(w io.Writer, i int) (int, error) { return io.WriteString(w, ab.A[i]) }
+func (ab *Strings) WriteBTo(w io.Writer, i int) (int, error) { return io.WriteString(w, ab.B[i]) }
+
+// Counting is a pair of int slices, without keys, that counts calls to Equal.
+type Counting struct {
+	A, B []int
+	N    int // number of calls to Equal
+}
+
+func (ab *Counting) LenA() int { return len(ab.A) }
+func (ab *Counting) LenB() int { return len(ab.B) }
+
+func (ab *Counting) Equal(ai, bi int) bool {
+	ab.N++
+	return ab.A[ai] == ab.B[bi]
+}
+
+// RandString returns a string of n bytes chosen at random from alphabet.
+func RandString(rng *rand.Rand, n int, alphabet string) string {
+	buf := make([]byte, n)
+	for i := range buf {
+		buf[i] = alphabet[rng.Intn(len(alphabet))]
+	}
+	return string(buf)
+}
+
+// CheckScript checks that e is a complete edit script from a to b,
+// diffed byte by byte, with the same shape as the output of myers.Diff:
+// no empty ranges, no adjacent ranges with the same operation,
+// and no deletion immediately after an insertion.
+// As in the output of myers.Diff, an insertion that follows
+// a deletion of all of A may be anchored at 0.
+func CheckScript(a, b string, e edit.Script) error {
+	var x, y int
+	for i, r := range e.Ranges {
+		anchored := i == 1 && r.IsInsert() && r.LowA == 0 && e.Ranges[0].IsDelete() && x == len(a)
+		if (r.LowA != x && !anchored) || r.LowB != y || r.Len() == 0 {
+			return fmt.Errorf("a=%q b=%q: malformed script %v", a, b, e)
+		}
+		if i > 0 {
+			prev, curr := e.Ranges[i-1].Op(), r.Op()
+			if prev == curr || (prev == edit.Ins && curr == edit.Del) {
+				return fmt.Errorf("a=%q b=%q: bad script %v", a, b, e)
+			}
+		}
+		switch r.Op() {
+		case edit.Eq:
+			if a[r.LowA:r.HighA] != b[r.LowB:r.HighB] {
+				return fmt.Errorf("a=%q b=%q: unequal Eq range %v", a, b, r)
+			}
+			x, y = r.HighA, r.HighB
+		case edit.Del:
+			x = r.HighA
+		case edit.Ins:
+			y = r.HighB
+		default:
+			return fmt.Errorf("a=%q b=%q: unexpected op in script %v", a, b, e)
+		}
+	}
+	if x != len(a) || y != len(b) {
+		return fmt.Errorf("a=%q b=%q: incomplete script %v", a, b, e)
+	}
+	return nil
+}
@@ -0,0 +1,196 @@
+package pairs
+
+import (
+	"github.com/pkg/diff/edit"
+	"github.com/pkg/diff/internal/build"
+)
+
+// Compact slides the changes in e, an edit script for A and B of lengths lenA and lenB,
+// as git's xdl_change_compact does for every diff algorithm,
+// using the keys provided by k to compare elements within A and within B.
+// e must cover all of A and B.
+//
+// Each run of changed elements is slid as far up and then as far down as
+// its neighbouring equal elements allow, merging with any runs it meets.
+// If some position lines it up with a change in the other side,
+// it is moved to the last such position; otherwise it stays at the bottom.
+func Compact(e edit.Script, lenA, lenB int, k Keyer) edit.Script {
+	a := &side{changed: make([]bool, lenA), key: k.KeyA}
+	b := &side{changed: make([]bool, lenB), key: k.KeyB}
+	for _, r := range e.Ranges {
+		switch r.Op() {
+		case edit.Del:
+			for i := r.LowA; i < r.HighA; i++ {
+				a.changed[i] = true
+			}
+		case edit.Ins:
+			for i := r.LowB; i < r.HighB; i++ {
+				b.changed[i] = true
+			}
+		}
+	}
+	a.compact(b)
+	b.compact(a)
+
+	var s build.Builder
+	for x, y := 0, 0; x < lenA || y < lenB; {
+		del, ins := 0, 0
+		for x+del < lenA && a.changed[x+del] {
+			del++
+		}
+		for y+ins < lenB && b.changed[y+ins] {
+			ins++
+		}
+		if del == 0 && ins == 0 {
+			s.Eq(1)
+			x++
+			y++
+			continue
+		}
+		s.Del(del)
+		s.Ins(ins)
+		x += del
+		y += ins
+	}
+	return Script(&s)
+}
+
+// A side records which elements of one side of a diff are changed.
+type side struct {
+	changed []bool
+	key     func(i int) interface{}
+}
+
+// A group is a run of changed elements, [start, end).
+// It is empty if the changes in the other side have no counterpart here.
+type group struct {
+	start, end int
+}
+
+// isChanged reports whether element i is changed.
+// Elements outside the side are unchanged.
+func (s *side) isChanged(i int) bool {
+	return 0 <= i && i < len(s.changed) && s.changed[i]
+}
+
+// first returns the first, possibly empty, group of s.
+func (s *side) first() group {
+	var g group
+	for s.isChanged(g.end) {
+		g.end++
+	}
+	return g
+}
+
+// next moves g to the next, possibly empty, group of s.
+// It reports false if g is the last group.
+func (s *side) next(g *group) bool {
+	if g.end == len(s.changed) {
+		return false
+	}
+	g.start = g.end + 1
+	for g.end = g.start; s.isChanged(g.end); g.end++ {
+	}
+	return true
+}
+
+// previous moves g to the previous, possibly empty, group of s.
+// It reports false if g is the first group.
+func (s *side) previous(g *group) bool {
+	if g.start == 0 {
+		return false
+	}
+	g.end = g.start - 1
+	for g.start = g.end; s.isChanged(g.start - 1); g.start-- {
+	}
+	return true
+}
+
+// slideDown slides g down by one element, if the element after g equals its first,
+// merging g with the group that follows it if they meet.
+// It reports whether g moved.
+func (s *side) slideDown(g *group) bool {
+	if g.end == len(s.changed) || s.key(g.start) != s.key(g.end) {
+		return false
+	}
+	s.changed[g.start] = false
+	s.changed[g.end] = true
+	g.start++
+	g.end++
+	for s.isChanged(g.end) {
+		g.end++
+	}
+	return true
+}
+
+// slideUp slides g up by one element, if the element before g equals its last,
+// merging g with the group that precedes it if they meet.
+// It reports whether g moved.
+func (s *side) slideUp(g *group) bool {
+	if g.start == 0 || s.key(g.start-1) != s.key(g.end-1) {
+		return false
+	}
+	g.start--
+	g.end--
+	s.changed[g.start] = true
+	s.changed[g.end] = false
+	for s.isChanged(g.start - 1) {
+		g.start--
+	}
+	return true
+}
+
+// compact slides the groups of s, keeping the groups of o in step.
+func (s *side) compact(o *side) {
+	g, og := s.first(), o.first()
+	for {
+		if g.start != g.end {
+			var earliestEnd, endMatchingOther int
+			for {
+				size := g.end - g.start
+				// Slide g as far up as possible.
+				for s.slideUp(&g) {
+					if !o.previous(&og) {
+						panic("pairs: group sync broken sliding up")
+					}
+				}
+				earliestEnd = g.end
+				endMatchingOther = -1
+				if og.start != og.end {
+					endMatchingOther = g.end
+				}
+				// Then as far down as possible,
+				// remembering the last position that lines up with a change in o.
+				for s.slideDown(&g) {
+					if !o.next(&og) {
+						panic("pairs: group sync broken sliding down")
+					}
+					if og.start != og.end {
+						endMatchingOther = g.end
+					}
+				}
+				if size == g.end-g.start {
+					break
+				}
+				// g merged with another group; slide the result again.
+			}
+			if g.end != earliestEnd && endMatchingOther != -1 {
+				// Slide g back up to line up with the change in o.
+				for og.start == og.end {
+					if !s.slideUp(&g) {
+						panic("pairs: match disappeared")
+					}
+					if !o.previous(&og) {
+						panic("pairs: group sync broken sliding to match")
+					}
+				}
+			}
+		}
+		if !s.next(&g) {
+			break
+		}
+		if !o.next(&og) {
+			panic("pairs: group sync broken moving to next group")
+		}
+	}
+}
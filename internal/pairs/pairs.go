@@ -0,0 +1,102 @@
+// Package pairs provides helpers for diff algorithms that operate on myers.Pairs.
+package pairs
+
+import (
+	"github.com/pkg/diff/edit"
+	"github.com/pkg/diff/internal/build"
+	"github.com/pkg/diff/myers"
+)
+
+// A Keyer is a myers.Pair that can provide map keys for its elements.
+// Keys must be comparable values that are equal exactly when the elements are equal.
+type Keyer interface {
+	KeyA(ai int) interface{}
+	KeyB(bi int) interface{}
+}
+
+// Classes assigns each element of A and B, of lengths aLen and bLen,
+// an equivalence class, using the keys provided by k.
+// Equal elements share a class. Elements of A or B
+// that have no equal in the other are assigned class -1.
+func Classes(aLen, bLen int, k Keyer) (classA, classB []int) {
+	classA = make([]int, aLen)
+	classB = make([]int, bLen)
+	m := make(map[interface{}]int)
+	for bi := range classB {
+		key := k.KeyB(bi)
+		c, ok := m[key]
+		if !ok {
+			c = len(m)
+			m[key] = c
+		}
+		classB[bi] = c
+	}
+	seen := make(map[int]bool)
+	for ai := range classA {
+		c, ok := m[k.KeyA(ai)]
+		if !ok {
+			c = -1
+		}
+		classA[ai] = c
+		seen[c] = true
+	}
+	for bi, c := range classB {
+		if !seen[c] {
+			classB[bi] = -1
+		}
+	}
+	return classA, classB
+}
+
+// Sub returns the portion of ab consisting of A[lowA:highA] and B[lowB:highB].
+// If ab is a Keyer, so is the returned Pair.
+func Sub(ab myers.Pair, lowA, highA, lowB, highB int) myers.Pair {
+	p := &sub{ab: ab, lowA: lowA, lowB: lowB, lenA: highA - lowA, lenB: highB - lowB}
+	if k, ok := ab.(Keyer); ok {
+		return &keyedSub{sub: p, k: k}
+	}
+	return p
+}
+
+// A sub is a portion of a myers.Pair.
+type sub struct {
+	ab         myers.Pair
+	lowA, lowB int
+	lenA, lenB int
+}
+
+func (p *sub) LenA() int             { return p.lenA }
+func (p *sub) LenB() int             { return p.lenB }
+func (p *sub) Equal(ai, bi int) bool { return p.ab.Equal(p.lowA+ai, p.lowB+bi) }
+
+// A keyedSub is a sub whose underlying myers.Pair is a Keyer.
+type keyedSub struct {
+	*sub
+	k Keyer
+}
+
+func (p *keyedSub) KeyA(ai int) interface{} { return p.k.KeyA(p.lowA + ai) }
+func (p *keyedSub) KeyB(bi int) interface{} { return p.k.KeyB(p.lowB + bi) }
+
+// Add appends n elements of operation op, which must be Eq, Ins, or Del, to b.
+func Add(b *build.Builder, op edit.Op, n int) {
+	switch op {
+	case edit.Eq:
+		b.Eq(n)
+	case edit.Del:
+		b.Del(n)
+	case edit.Ins:
+		b.Ins(n)
+	default:
+		panic("pairs: Add called with op " + op.String())
+	}
+}
+
+// Script returns the edit script built by b.
+func Script(b *build.Builder) edit.Script {
+	var e edit.Script
+	for _, r := range b.Ranges() {
+		e.Ranges = append(e.Ranges, edit.Range{LowA: r.LowA, HighA: r.HighA, LowB: r.LowB, HighB: r.HighB})
+	}
+	return e
+}
@@ -0,0 +1,147 @@
+// Package lazy diffs files that are too big to fit in memory.
+//
+// Rather than reading its inputs into memory, a Pair scans each of them once,
+// storing only the offset of each line and a small integer identifying its contents,
+// as assigned by package hashline.
+// Lines are read back with ReadAt as needed,
+// relying on the OS page cache for performance.
+//
+// A typical use is:
+//
+//	ab, err := lazy.NewPair(aFile, bFile)
+//	if err != nil {
+//		// handle err
+//	}
+//	e, err := myers.DiffErr(ctx, ab)
+//	if err != nil {
+//		// handle err
+//	}
+//	err = write.Unified(ctxt.Size(e, 3), w, ab)
+package lazy
+
+import (
+	"bufio"
+	"bytes"
+	"io"
+	"math"
+
+	"github.com/pkg/diff/hashline"
+)
+
+// A Pair is a pair of line-oriented io.ReaderAts.
+// It implements myers.Pair and write.NewlinePair.
+// It also provides the KeyA and KeyB methods used
+// by the patience and histogram packages.
+//
+// As with diff.Text, lines are split at each newline.
+// The newline is not part of the line, but any other line terminator,
+// such as the carriage return of a "\r\n", is.
+type Pair struct {
+	a, b side
+}
+
+// A side is one of the two inputs of a Pair.
+type side struct {
+	r io.ReaderAt
+	// off[i] is the offset of line i.
+	// off[len(off)-1] is the offset of the end of the input.
+	off []int64
+	// id[i] identifies the contents of line i.
+	// Lines are equal exactly when their ids are equal.
+	// A final line that lacks a trailing newline has a negative id,
+	// the bitwise complement of the id of its contents,
+	// so that it is not equal to any line that has a trailing newline.
+	id []int
+}
+
+func (s *side) Len() int { return len(s.id) }
+
+// NewPair scans a and b and returns a Pair for them.
+// Each input is read from offset 0 until io.EOF.
+func NewPair(a, b io.ReaderAt) (*Pair, error) {
+	ab := &Pair{a: side{r: a}, b: side{r: b}}
+	t := hashline.NewTable()
+	if err := ab.a.index(t); err != nil {
+		return nil, err
+	}
+	if err := ab.b.index(t); err != nil {
+		return nil, err
+	}
+	return ab, nil
+}
+
+func (ab *Pair) LenA() int                                 { return len(ab.a.id) }
+func (ab *Pair) LenB() int                                 { return len(ab.b.id) }
+func (ab *Pair) Equal(ai, bi int) bool                     { return ab.a.id[ai] == ab.b.id[bi] }
+func (ab *Pair) KeyA(ai int) interface{}                   { return ab.a.id[ai] }
+func (ab *Pair) KeyB(bi int) interface{}                   { return ab.b.id[bi] }
+func (ab *Pair) NoNewlineA(ai int) bool                    { return ab.a.id[ai] < 0 }
+func (ab *Pair) NoNewlineB(bi int) bool                    { return ab.b.id[bi] < 0 }
+func (ab *Pair) WriteATo(w io.Writer, ai int) (int, error) { return ab.a.writeTo(w, ai) }
+func (ab *Pair) WriteBTo(w io.Writer, bi int) (int, error) { return ab.b.writeTo(w, bi) }
+
+// AppendLine appends line i to dst and returns the extended buffer.
+func (s *side) AppendLine(dst []byte, i int) ([]byte, error) {
+	n := int(s.off[i+1] - s.off[i])
+	start := len(dst)
+	dst = append(dst, make([]byte, n)...)
+	buf := dst[start:]
+	if _, err := s.r.ReadAt(buf, s.off[i]); err != nil && err != io.EOF {
+		return nil, err
+	}
+	return dst[:start+len(bytes.TrimSuffix(buf, []byte{'\n'}))], nil
+}
+
+func (s *side) writeTo(w io.Writer, i int) (int, error) {
+	line, err := s.AppendLine(nil, i)
+	if err != nil {
+		return 0, err
+	}
+	return w.Write(line)
+}
+
+// index scans s.r, populating s.off and s.id using t.
+func (s *side) index(t *hashline.Table) error {
+	br := bufio.NewReaderSize(io.NewSectionReader(s.r, 0, math.MaxInt64), 64<<10)
+	h := t.NewHash()
+	var off int64
+	s.off = append(s.off, 0)
+	for {
+		h.Reset()
+		start := off
+		eof := false
+	line:
+		for {
+			chunk, err := br.ReadSlice('\n')
+			off += int64(len(chunk))
+			switch err {
+			case nil:
+				h.Write(chunk[:len(chunk)-1])
+				break line
+			case bufio.ErrBufferFull:
+				// The line is longer than the buffer.
+				h.Write(chunk)
+			case io.EOF:
+				h.Write(chunk)
+				eof = true
+				break line
+			default:
+				return err
+			}
+		}
+		if off == start {
+			return nil
+		}
+		s.off = append(s.off, off)
+		id, err := t.ID(h.Sum64(), s, len(s.id))
+		if err != nil {
+			return err
+		}
+		if eof {
+			// The final line lacks a trailing newline.
+			s.id = append(s.id, ^id)
+			return nil
+		}
+		s.id = append(s.id, id)
+	}
+}
@@ -0,0 +1,112 @@
+package lazy_test
+
+import (
+	"bytes"
+	"context"
+	"io/ioutil"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/pkg/diff"
+	"github.com/pkg/diff/ctxt"
+	"github.com/pkg/diff/lazy"
+	"github.com/pkg/diff/myers"
+	"github.com/pkg/diff/write"
+)
+
+func TestGolden(t *testing.T) {
+	const (
+		aPath = "../testdata/rewriteAMD64.go.a"
+		bPath = "../testdata/rewriteAMD64.go.b"
+	)
+	want := new(bytes.Buffer)
+	if err := diff.Text(aPath, bPath, nil, nil, want); err != nil {
+		t.Fatal(err)
+	}
+
+	a, err := os.Open(aPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer a.Close()
+	b, err := os.Open(bPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer b.Close()
+
+	ab, err := lazy.NewPair(a, b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	e, err := myers.DiffErr(context.Background(), ab)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := new(bytes.Buffer)
+	if err := write.Unified(ctxt.Size(e, 3), got, ab, write.Names(aPath, bPath)); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got.Bytes(), want.Bytes()) {
+		t.Errorf("lazy diff differs from diff.Text")
+	}
+}
+
+func TestLines(t *testing.T) {
+	long := strings.Repeat("x", 64<<10-1)
+	tests := []struct {
+		name  string
+		a, b  string
+		equal []bool // whether a[i] == b[i]
+	}{
+		{name: "Empty", a: "", b: ""},
+		{name: "NoFinalNewline", a: "a\nb\n", b: "a\nb", equal: []bool{true, false}},
+		{name: "NoFinalNewlines", a: "a\nb", b: "a\nb", equal: []bool{true, true}},
+		{name: "CRLF", a: "a\r\nb\r\n", b: "a\nb\r\n", equal: []bool{false, true}},
+		{name: "InnerCR", a: "a\rb\n", b: "ab\n", equal: []bool{false}},
+		{name: "EmptyLines", a: "\n\n", b: "\n\r\n", equal: []bool{true, false}},
+		{name: "Long", a: long + "xy\n", b: long + "xy\n", equal: []bool{true}},
+		{name: "LongCRLF", a: long + "\r\n", b: long + "\n", equal: []bool{false}},
+		{name: "LongDifferent", a: long + "a\n", b: long + "b\n", equal: []bool{false}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ab, err := lazy.NewPair(strings.NewReader(test.a), strings.NewReader(test.b))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if ab.LenA() != len(test.equal) || ab.LenB() != len(test.equal) {
+				t.Fatalf("got %d and %d lines, want %d", ab.LenA(), ab.LenB(), len(test.equal))
+			}
+			for i, want := range test.equal {
+				if got := ab.Equal(i, i); got != want {
+					t.Errorf("Equal(%d, %d) = %v, want %v", i, i, got, want)
+				}
+			}
+			for i := 0; i < ab.LenA(); i++ {
+				buf := new(bytes.Buffer)
+				if _, err := ab.WriteATo(buf, i); err != nil {
+					t.Fatal(err)
+				}
+				want := strings.TrimSuffix(strings.SplitAfter(test.a, "\n")[i], "\n")
+				if buf.String() != want {
+					t.Errorf("WriteATo(%d) wrote %q, want %q", i, buf, want)
+				}
+			}
+		})
+	}
+}
+
+func TestReadError(t *testing.T) {
+	f, err := ioutil.TempFile("", "lazy")
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.Close()
+	os.Remove(f.Name())
+	// Reading from a closed file fails.
+	if _, err := lazy.NewPair(f, strings.NewReader("")); err == nil {
+		t.Errorf("NewPair succeeded reading a closed file")
+	}
+}
@@ -0,0 +1,425 @@
+// Package merge implements three-way merges of text.
+package merge
+
+import (
+	"bufio"
+	"context"
+	"io"
+	"strings"
+
+	"github.com/pkg/diff/edit"
+	"github.com/pkg/diff/internal/pairs"
+	"github.com/pkg/diff/myers"
+)
+
+// A Chunk is a region of a merge result.
+// It either merged cleanly or is a conflict.
+type Chunk struct {
+	// Conflict reports whether ours and theirs made conflicting changes to the chunk.
+	Conflict bool
+	// Merged holds the lines of a chunk that merged cleanly.
+	Merged []string
+	// Base, Ours, and Theirs hold the lines of a conflict
+	// in base and in each of the merged versions.
+	Base, Ours, Theirs []string
+
+	// changed reports whether Merged includes a change made by only one
+	// of ours and theirs, or by both to different lines of base.
+	// As in git, such a change keeps Write from joining the conflicts around it.
+	changed bool
+}
+
+// splitLines splits text into lines, keeping their newlines.
+func splitLines(text []byte) []string {
+	var lines []string
+	s := string(text)
+	for len(s) > 0 {
+		i := strings.IndexByte(s, '\n')
+		if i < 0 {
+			lines = append(lines, s)
+			break
+		}
+		lines = append(lines, s[:i+1])
+		s = s[i+1:]
+	}
+	return lines
+}
+
+// diffLines is a myers.Pair of lines.
+type diffLines struct {
+	a, b []string
+}
+
+func (ab *diffLines) LenA() int               { return len(ab.a) }
+func (ab *diffLines) LenB() int               { return len(ab.b) }
+func (ab *diffLines) Equal(ai, bi int) bool   { return ab.a[ai] == ab.b[bi] }
+func (ab *diffLines) KeyA(ai int) interface{} { return ab.a[ai] }
+func (ab *diffLines) KeyB(bi int) interface{} { return ab.b[bi] }
+
+// A change replaces base[lowBase:highBase] with side[lowSide:highSide].
+type change struct {
+	lowBase, highBase int
+	lowSide, highSide int
+}
+
+// changes returns the changes made by e, in order.
+// Consecutive deletions and insertions form a single change.
+func changes(e edit.Script) []change {
+	var cs []change
+	for i := 0; i < len(e.Ranges); {
+		r := e.Ranges[i]
+		if r.IsEqual() {
+			i++
+			continue
+		}
+		c := change{lowBase: r.LowA, highBase: r.HighA, lowSide: r.LowB, highSide: r.HighB}
+		for i++; i < len(e.Ranges) && !e.Ranges[i].IsEqual(); i++ {
+			// When there is nothing in common, myers.Diff anchors
+			// the insertion at the start of base, so use only the
+			// deletion's base range and the insertion's side range.
+			if r := e.Ranges[i]; r.IsInsert() {
+				c.highSide = r.HighB
+			} else {
+				c.highBase = r.HighA
+			}
+		}
+		cs = append(cs, c)
+	}
+	return cs
+}
+
+// Merge merges the changes from base to ours with the changes from base to theirs.
+// It diffs base against each of ours and theirs using myers.DiffErr.
+// Where ours and theirs change the same or adjacent lines of base differently,
+// the result contains a conflict.
+//
+// The texts are split into lines at each newline; unlike with diff.Text,
+// each line includes its newline, if any.
+// Merge returns ctx.Err() if ctx is cancelled before the merge is complete.
+func Merge(ctx context.Context, base, ours, theirs []byte) ([]Chunk, error) {
+	b, o, t := splitLines(base), splitLines(ours), splitLines(theirs)
+	eo, err := myers.DiffErr(ctx, &diffLines{a: b, b: o})
+	if err != nil {
+		return nil, err
+	}
+	et, err := myers.DiffErr(ctx, &diffLines{a: b, b: t})
+	if err != nil {
+		return nil, err
+	}
+	co, ct := changes(eo), changes(et)
+
+	var chunks []Chunk
+	pos := 0               // index in base of the first line not yet merged
+	deltaO, deltaT := 0, 0 // offsets from base to ours and theirs at pos
+	for len(co) > 0 || len(ct) > 0 {
+		// Find the next group of overlapping or adjacent changes.
+		var lo int
+		switch {
+		case len(ct) == 0 || len(co) > 0 && co[0].lowBase <= ct[0].lowBase:
+			lo = co[0].lowBase
+		default:
+			lo = ct[0].lowBase
+		}
+		hi := lo
+		var no, nt int // number of changes in the group from ours and theirs
+	group:
+		for {
+			switch {
+			case no < len(co) && co[no].lowBase <= hi:
+				hi = max(hi, co[no].highBase)
+				no++
+			case nt < len(ct) && ct[nt].lowBase <= hi:
+				hi = max(hi, ct[nt].highBase)
+				nt++
+			default:
+				break group
+			}
+		}
+		if pos < lo {
+			chunks = appendMerged(chunks, b[pos:lo], false)
+		}
+		oLines, newDeltaO := side(o, co[:no], lo, hi, deltaO)
+		tLines, newDeltaT := side(t, ct[:nt], lo, hi, deltaT)
+		switch {
+		case nt == 0:
+			chunks = appendMerged(chunks, oLines, true)
+		case no == 0:
+			chunks = appendMerged(chunks, tLines, true)
+		case equal(oLines, tLines):
+			// Both made the same change. As in git, the change separates
+			// the conflicts around it unless both made it to the same lines of base.
+			same := no == 1 && nt == 1 && co[0].lowBase == ct[0].lowBase && co[0].highBase == ct[0].highBase
+			chunks = appendMerged(chunks, oLines, !same)
+		default:
+			chunks = append(chunks, Chunk{Conflict: true, Base: b[lo:hi], Ours: oLines, Theirs: tLines})
+		}
+		co, ct = co[no:], ct[nt:]
+		deltaO, deltaT = newDeltaO, newDeltaT
+		pos = hi
+	}
+	if pos < len(b) {
+		chunks = appendMerged(chunks, b[pos:], false)
+	}
+	return chunks, nil
+}
+
+// side returns the lines of x that replace base[lo:hi], given the changes cs
+// made by x within that range, and the offset delta from base to x at lo.
+// It also returns the offset from base to x at hi.
+func side(x []string, cs []change, lo, hi, delta int) ([]string, int) {
+	if len(cs) == 0 {
+		return x[lo+delta : hi+delta], delta
+	}
+	first, last := cs[0], cs[len(cs)-1]
+	low := first.lowSide - (first.lowBase - lo)
+	high := last.highSide + (hi - last.highBase)
+	return x[low:high], high - hi
+}
+
+// appendMerged appends lines that merged cleanly to chunks.
+// changed reports whether the lines include a change that separates conflicts,
+// as described for Chunk.changed.
+func appendMerged(chunks []Chunk, lines []string, changed bool) []Chunk {
+	if n := len(chunks); n > 0 && !chunks[n-1].Conflict {
+		chunks[n-1].Merged = append(chunks[n-1].Merged, lines...)
+		chunks[n-1].changed = chunks[n-1].changed || changed
+		return chunks
+	}
+	if len(lines) == 0 {
+		// A deletion right after a conflict would be part of it,
+		// so there is no merged chunk to record the change in.
+		return chunks
+	}
+	return append(chunks, Chunk{Merged: append([]string(nil), lines...), changed: changed})
+}
+
+func equal(x, y []string) bool {
+	if len(x) != len(y) {
+		return false
+	}
+	for i := range x {
+		if x[i] != y[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func max(x, y int) int {
+	if x > y {
+		return x
+	}
+	return y
+}
+
+// A Style is a way of writing conflicts, as with git's merge.conflictStyle.
+type Style int
+
+const (
+	// StyleMerge writes ours and theirs.
+	// As git does by default, it diffs ours against theirs within each conflict
+	// and writes the lines they share outside the conflict, splitting it.
+	// It then joins conflicts separated only by unchanged lines,
+	// if there are at most three of them or they contain no letters or digits.
+	StyleMerge Style = iota
+	// StyleDiff3 writes ours, base, and theirs.
+	StyleDiff3
+	// StyleZDiff3 writes ours, base, and theirs.
+	// As with StyleMerge, lines at the start and end of a conflict
+	// that ours and theirs share are written outside the conflict.
+	StyleZDiff3
+)
+
+// An Option modifies behavior when writing a merge result.
+type Option interface {
+	isOption()
+}
+
+// ConflictStyle specifies how to write conflicts.
+// The default is StyleMerge.
+func ConflictStyle(s Style) Option {
+	return styleOpt(s)
+}
+
+type styleOpt Style
+
+func (styleOpt) isOption() {}
+
+// Labels provides the labels written after conflict markers.
+// They are traditionally names of files or branches.
+// The default labels are "ours", "base", and "theirs".
+func Labels(ours, base, theirs string) Option {
+	return labels{ours, base, theirs}
+}
+
+type labels struct {
+	ours, base, theirs string
+}
+
+func (labels) isOption() {}
+
+// Write writes chunks to w.
+// Conflicts are delimited by conflict markers, as written by git.
+// Write returns the number of conflicts written and the first error (if any) encountered.
+func Write(w io.Writer, chunks []Chunk, options ...Option) (conflicts int, err error) {
+	style := StyleMerge
+	l := labels{"ours", "base", "theirs"}
+	for _, opt := range options {
+		switch opt := opt.(type) {
+		case styleOpt:
+			style = Style(opt)
+		case labels:
+			l = opt
+		}
+	}
+
+	bw := bufio.NewWriter(w)
+	writeLines := func(lines []string) {
+		for _, line := range lines {
+			bw.WriteString(line)
+		}
+	}
+	// writeSide writes the lines of one side of a conflict,
+	// ensuring that the marker that follows starts a new line.
+	writeSide := func(lines []string) {
+		writeLines(lines)
+		if n := len(lines); n > 0 && !strings.HasSuffix(lines[n-1], "\n") {
+			bw.WriteByte('\n')
+		}
+	}
+	marker := func(m, label string) {
+		bw.WriteString(m)
+		if label != "" {
+			bw.WriteByte(' ')
+			bw.WriteString(label)
+		}
+		bw.WriteByte('\n')
+	}
+
+	if style == StyleMerge {
+		chunks = join(refine(chunks))
+	}
+	for _, c := range chunks {
+		if !c.Conflict {
+			writeLines(c.Merged)
+			continue
+		}
+		ours, theirs := c.Ours, c.Theirs
+		var prefix, suffix []string
+		if style == StyleZDiff3 {
+			prefix, suffix, ours, theirs = trimCommon(ours, theirs)
+		}
+		conflicts++
+		writeLines(prefix)
+		marker("<<<<<<<", l.ours)
+		writeSide(ours)
+		if style != StyleMerge {
+			marker("|||||||", l.base)
+			writeSide(c.Base)
+		}
+		marker("=======", "")
+		writeSide(theirs)
+		marker(">>>>>>>", l.theirs)
+		writeLines(suffix)
+	}
+	return conflicts, bw.Flush()
+}
+
+// refine splits each conflict in chunks at the lines that ours and theirs share,
+// as found by diffing them, as git's xdl_refine_conflicts does.
+func refine(chunks []Chunk) []Chunk {
+	var out []Chunk
+	for _, c := range chunks {
+		switch {
+		case !c.Conflict:
+			out = appendMerged(out, c.Merged, c.changed)
+			continue
+		case equal(c.Ours, c.Theirs):
+			out = appendMerged(out, c.Ours, true)
+			continue
+		case len(c.Ours) == 0 || len(c.Theirs) == 0:
+			out = append(out, c)
+			continue
+		}
+		ab := &diffLines{a: c.Ours, b: c.Theirs}
+		e := pairs.Compact(myers.Diff(context.Background(), ab), ab.LenA(), ab.LenB(), ab)
+		pos := 0
+		for _, ch := range changes(e) {
+			out = appendMerged(out, c.Ours[pos:ch.lowBase], false)
+			out = append(out, Chunk{Conflict: true, Ours: c.Ours[ch.lowBase:ch.highBase], Theirs: c.Theirs[ch.lowSide:ch.highSide]})
+			pos = ch.highBase
+		}
+		out = appendMerged(out, c.Ours[pos:], false)
+	}
+	return out
+}
+
+// join joins conflicts separated only by unchanged lines,
+// if there are at most three of them or they contain no letters or digits,
+// as git's xdl_simplify_non_conflicts does at its default merge level.
+func join(chunks []Chunk) []Chunk {
+	var out []Chunk
+	for _, c := range chunks {
+		n := len(out)
+		if !c.Conflict || n < 2 || !out[n-2].Conflict || out[n-1].changed {
+			out = append(out, c)
+			continue
+		}
+		between := out[n-1].Merged
+		if len(between) > 3 && hasAlnum(between) {
+			out = append(out, c)
+			continue
+		}
+		prev := &out[n-2]
+		prev.Ours = concat(prev.Ours, between, c.Ours)
+		prev.Theirs = concat(prev.Theirs, between, c.Theirs)
+		out = out[:n-1]
+	}
+	return out
+}
+
+// hasAlnum reports whether lines contain an ASCII letter or digit.
+func hasAlnum(lines []string) bool {
+	for _, line := range lines {
+		for i := 0; i < len(line); i++ {
+			if c := line[i]; 'a' <= c && c <= 'z' || 'A' <= c && c <= 'Z' || '0' <= c && c <= '9' {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func concat(x ...[]string) []string {
+	var all []string
+	for _, lines := range x {
+		all = append(all, lines...)
+	}
+	return all
+}
+
+// trimCommon splits off the lines at the start and end of x and y that they share.
+func trimCommon(x, y []string) (prefix, suffix, xMid, yMid []string) {
+	n := 0
+	for n < len(x) && n < len(y) && x[n] == y[n] {
+		n++
+	}
+	prefix, x, y = x[:n], x[n:], y[n:]
+	m := 0
+	for m < len(x) && m < len(y) && x[len(x)-1-m] == y[len(y)-1-m] {
+		m++
+	}
+	suffix = x[len(x)-m:]
+	return prefix, suffix, x[:len(x)-m], y[:len(y)-m]
+}
+
+// Text merges base, ours, and theirs as described by Merge,
+// and writes the result to w as described by Write.
+// It returns the number of conflicts and the first error (if any) encountered.
+func Text(ctx context.Context, base, ours, theirs []byte, w io.Writer, options ...Option) (conflicts int, err error) {
+	chunks, err := Merge(ctx, base, ours, theirs)
+	if err != nil {
+		return 0, err
+	}
+	return Write(w, chunks, options...)
+}
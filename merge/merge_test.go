@@ -0,0 +1,197 @@
+package merge_test
+
+import (
+	"bytes"
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/pkg/diff/merge"
+)
+
+// The expected outputs of these tests were generated by git merge-file.
+var mergeTests = []struct {
+	name                 string
+	base, ours, theirs   string
+	merge, diff3, zdiff3 string // output in each style; empty means the same as merge
+	conflicts            int
+	mergeConflicts       int // conflicts in the merge style; zero means conflicts
+}{
+	{
+		name:   "Clean",
+		base:   "1\n2\n3\n4\n5\n",
+		ours:   "1\nX\n3\n4\n5\n",
+		theirs: "1\n2\n3\nY\n5\n",
+		merge:  "1\nX\n3\nY\n5\n",
+	},
+	{
+		name:   "SameChange",
+		base:   "1\n2\n3\n",
+		ours:   "1\nX\n3\n",
+		theirs: "1\nX\n3\n",
+		merge:  "1\nX\n3\n",
+	},
+	{
+		name:      "Conflict",
+		base:      "1\n2\n3\n4\n5\n6\n7\n",
+		ours:      "1\nX\nsame\n4\n5\n6\n7\n",
+		theirs:    "1\nY\nsame\n4\n5\nT\n7\n",
+		merge:     "1\n<<<<<<< ours\nX\n=======\nY\n>>>>>>> theirs\nsame\n4\n5\nT\n7\n",
+		diff3:     "1\n<<<<<<< ours\nX\nsame\n||||||| base\n2\n3\n=======\nY\nsame\n>>>>>>> theirs\n4\n5\nT\n7\n",
+		zdiff3:    "1\n<<<<<<< ours\nX\n||||||| base\n2\n3\n=======\nY\n>>>>>>> theirs\nsame\n4\n5\nT\n7\n",
+		conflicts: 1,
+	},
+	{
+		name:      "Adjacent",
+		base:      "1\n2\n3\n4\n",
+		ours:      "1\nX\n3\n4\n",
+		theirs:    "1\n2\nY\n4\n",
+		merge:     "1\n<<<<<<< ours\nX\n3\n=======\n2\nY\n>>>>>>> theirs\n4\n",
+		diff3:     "1\n<<<<<<< ours\nX\n3\n||||||| base\n2\n3\n=======\n2\nY\n>>>>>>> theirs\n4\n",
+		conflicts: 1,
+	},
+	{
+		name:      "NoNewline",
+		base:      "a\nb",
+		ours:      "a\nc",
+		theirs:    "a\nd",
+		merge:     "a\n<<<<<<< ours\nc\n=======\nd\n>>>>>>> theirs\n",
+		diff3:     "a\n<<<<<<< ours\nc\n||||||| base\nb\n=======\nd\n>>>>>>> theirs\n",
+		conflicts: 1,
+	},
+	{
+		name:      "NewlineOnly",
+		base:      "a\nb\n",
+		ours:      "a\nb\nc",
+		theirs:    "a\nb\nc\n",
+		merge:     "a\nb\n<<<<<<< ours\nc\n=======\nc\n>>>>>>> theirs\n",
+		diff3:     "a\nb\n<<<<<<< ours\nc\n||||||| base\n=======\nc\n>>>>>>> theirs\n",
+		conflicts: 1,
+	},
+	{
+		name:      "BothAppend",
+		base:      "a\n",
+		ours:      "a\nb\n",
+		theirs:    "a\nc\n",
+		merge:     "a\n<<<<<<< ours\nb\n=======\nc\n>>>>>>> theirs\n",
+		diff3:     "a\n<<<<<<< ours\nb\n||||||| base\n=======\nc\n>>>>>>> theirs\n",
+		conflicts: 1,
+	},
+	{
+		name:      "Conflicts",
+		base:      "1\n2\n3\n4\n5\n6\n",
+		ours:      "X\n2\n3\n4\n5\nX\n",
+		theirs:    "Y\n2\n3\n4\n5\nY\n",
+		merge:     "<<<<<<< ours\nX\n=======\nY\n>>>>>>> theirs\n2\n3\n4\n5\n<<<<<<< ours\nX\n=======\nY\n>>>>>>> theirs\n",
+		diff3:     "<<<<<<< ours\nX\n||||||| base\n1\n=======\nY\n>>>>>>> theirs\n2\n3\n4\n5\n<<<<<<< ours\nX\n||||||| base\n6\n=======\nY\n>>>>>>> theirs\n",
+		conflicts: 2,
+	},
+	{
+		name:           "NearbyConflicts",
+		base:           "1\n2\n3\n",
+		ours:           "X\n2\nX\n",
+		theirs:         "Y\n2\nY\n",
+		merge:          "<<<<<<< ours\nX\n2\nX\n=======\nY\n2\nY\n>>>>>>> theirs\n",
+		diff3:          "<<<<<<< ours\nX\n||||||| base\n1\n=======\nY\n>>>>>>> theirs\n2\n<<<<<<< ours\nX\n||||||| base\n3\n=======\nY\n>>>>>>> theirs\n",
+		conflicts:      2,
+		mergeConflicts: 1,
+	},
+	{
+		name:           "PunctuationBetween",
+		base:           "1\n}\n\n{\n\n2\n",
+		ours:           "X\n}\n\n{\n\nX\n",
+		theirs:         "Y\n}\n\n{\n\nY\n",
+		merge:          "<<<<<<< ours\nX\n}\n\n{\n\nX\n=======\nY\n}\n\n{\n\nY\n>>>>>>> theirs\n",
+		diff3:          "<<<<<<< ours\nX\n||||||| base\n1\n=======\nY\n>>>>>>> theirs\n}\n\n{\n\n<<<<<<< ours\nX\n||||||| base\n2\n=======\nY\n>>>>>>> theirs\n",
+		conflicts:      2,
+		mergeConflicts: 1,
+	},
+	{
+		name:      "ChangeBetween",
+		base:      "1\n2\n3\n4\n5\n",
+		ours:      "X\n2\nZ\n4\nX\n",
+		theirs:    "Y\n2\n3\n4\nY\n",
+		merge:     "<<<<<<< ours\nX\n=======\nY\n>>>>>>> theirs\n2\nZ\n4\n<<<<<<< ours\nX\n=======\nY\n>>>>>>> theirs\n",
+		diff3:     "<<<<<<< ours\nX\n||||||| base\n1\n=======\nY\n>>>>>>> theirs\n2\nZ\n4\n<<<<<<< ours\nX\n||||||| base\n5\n=======\nY\n>>>>>>> theirs\n",
+		conflicts: 2,
+	},
+}
+
+func TestText(t *testing.T) {
+	for _, test := range mergeTests {
+		styles := []struct {
+			name  string
+			style merge.Style
+			want  string
+		}{
+			{"Merge", merge.StyleMerge, test.merge},
+			{"Diff3", merge.StyleDiff3, test.diff3},
+			{"ZDiff3", merge.StyleZDiff3, test.zdiff3},
+		}
+		for _, s := range styles {
+			want, conflicts := s.want, test.conflicts
+			if s.style == merge.StyleMerge && test.mergeConflicts != 0 {
+				conflicts = test.mergeConflicts
+			}
+			if want == "" {
+				want = test.merge
+				if s.style == merge.StyleZDiff3 && test.diff3 != "" {
+					want = test.diff3
+				}
+			}
+			t.Run(test.name+"/"+s.name, func(t *testing.T) {
+				buf := new(bytes.Buffer)
+				n, err := merge.Text(context.Background(), []byte(test.base), []byte(test.ours), []byte(test.theirs), buf, merge.ConflictStyle(s.style))
+				if err != nil {
+					t.Fatal(err)
+				}
+				if got := buf.String(); got != want {
+					t.Errorf("got\n%s\nwant\n%s", got, want)
+				}
+				if n != conflicts {
+					t.Errorf("got %d conflicts, want %d", n, conflicts)
+				}
+			})
+		}
+	}
+}
+
+func TestMerge(t *testing.T) {
+	chunks, err := merge.Merge(context.Background(), []byte("1\n2\n3\n4\n"), []byte("1\nX\n3\n"), []byte("1\nY\n3\n4\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Drop the state that Merge keeps for Write.
+	for i, c := range chunks {
+		chunks[i] = merge.Chunk{Conflict: c.Conflict, Merged: c.Merged, Base: c.Base, Ours: c.Ours, Theirs: c.Theirs}
+	}
+	want := []merge.Chunk{
+		{Merged: []string{"1\n"}},
+		{Conflict: true, Base: []string{"2\n"}, Ours: []string{"X\n"}, Theirs: []string{"Y\n"}},
+		{Merged: []string{"3\n"}},
+	}
+	if !reflect.DeepEqual(chunks, want) {
+		t.Errorf("got %+v, want %+v", chunks, want)
+	}
+}
+
+func TestLabels(t *testing.T) {
+	buf := new(bytes.Buffer)
+	_, err := merge.Text(context.Background(), []byte("a\n"), []byte("b\n"), []byte("c\n"), buf,
+		merge.ConflictStyle(merge.StyleDiff3), merge.Labels("HEAD", "", "feature"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "<<<<<<< HEAD\nb\n|||||||\na\n=======\nc\n>>>>>>> feature\n"
+	if got := buf.String(); got != want {
+		t.Errorf("got\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestCancel(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := merge.Merge(ctx, []byte("a\nb\n"), []byte("b\nc\n"), []byte("c\nd\n")); err != context.Canceled {
+		t.Errorf("got error %v, want %v", err, context.Canceled)
+	}
+}
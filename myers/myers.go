@@ -20,164 +20,334 @@ type Pair interface {
 }
 
 // Diff calculates an edit.Script for ab using the Myers diff algorithm.
-// This implementation uses the algorithm described in the first half
-// of Myers' paper, which requires quadratric space.
-// (An implementation of the linear space version is forthcoming.)
+// This implementation uses the linear space refinement described
+// in the second half of Myers' paper: it repeatedly finds the
+// "middle snake" of an optimal path, and then recursively
+// diffs the portions before and after it.
 //
 // Because diff calculation can be expensive, Myers supports cancellation via ctx.
+// If ctx is cancelled, Diff returns an empty edit.Script,
+// which is indistinguishable from the diff of two empty inputs.
+// Use DiffErr to detect cancellation.
 func Diff(ctx context.Context, ab Pair) edit.Script {
+	e, _ := DiffErr(ctx, ab)
+	return e
+}
+
+// DiffErr is like Diff, but it reports cancellation.
+// If ctx is cancelled before the diff is complete,
+// DiffErr returns an empty edit.Script and ctx.Err().
+func DiffErr(ctx context.Context, ab Pair) (edit.Script, error) {
+	e, _, err := DiffLimit(ctx, ab, 0)
+	return e, err
+}
+
+// DiffLimit is like DiffErr, but it bounds the cost of the diff.
+//
+// Finding a minimal edit script for inputs with many differences can be very slow.
+// Each search for a middle snake gives up after exploring paths
+// with maxCost edits, and instead splits the problem at the most promising
+// point found so far, like GNU diff's TOO_EXPENSIVE heuristic.
+// The result is a valid edit script that may not be minimal;
+// DiffLimit reports whether it is.
+// If maxCost <= 0, the cost is unbounded, and the edit script is always minimal.
+func DiffLimit(ctx context.Context, ab Pair, maxCost int) (e edit.Script, minimal bool, err error) {
 	aLen := ab.LenA()
 	bLen := ab.LenB()
 	if aLen == 0 && bLen == 0 {
-		return edit.NewScript()
+		return edit.NewScript(), true, nil
 	}
 	if aLen == 0 {
-		return edit.NewScript(edit.Range{HighB: bLen})
+		return edit.NewScript(edit.Range{HighB: bLen}), true, nil
 	}
 	if bLen == 0 {
-		return edit.NewScript(edit.Range{HighA: aLen})
+		return edit.NewScript(edit.Range{HighA: aLen}), true, nil
 	}
 
 	max := aLen + bLen
 	if max < 0 {
 		panic("overflow in myers.Diff")
 	}
-	// v has indices -max .. 0 .. max
-	// access to elements of v have the form max + actual offset
-	v := make([]int, 2*max+1)
+	d := &differ{
+		ctx: ctx,
+		ab:  ab,
+		// vf and vb have indices -max .. 0 .. max
+		// access to elements have the form max + actual offset
+		vf:  make([]int, 2*max+2),
+		vb:  make([]int, 2*max+2),
+		off: max,
 
-	var trace [][]int
-search:
-	for d := 0; d < max; d++ {
-		// Only check context every 16th iteration to reduce overhead.
-		if ctx != nil && uint(d)%16 == 0 && ctx.Err() != nil {
-			return edit.Script{}
+		maxCost: maxCost,
+		minimal: true,
+	}
+	if !d.compare(0, aLen, 0, bLen) {
+		return edit.Script{}, false, ctx.Err()
+	}
+	e = d.script()
+
+	if len(e.Ranges) == 2 && e.Ranges[0].Len() == aLen && e.Ranges[1].Len() == bLen {
+		// No commonality at all, delete everything and then insert everything.
+		// The insertion is anchored at the start of A, as it always has been.
+		return edit.NewScript(edit.Range{HighA: aLen}, edit.Range{HighB: bLen}), d.minimal, nil
+	}
+
+	// Sanity check
+	for i := 1; i < len(e.Ranges); i++ {
+		prevop := e.Ranges[i-1].Op()
+		currop := e.Ranges[i].Op()
+		if (prevop == currop) || (prevop == edit.Ins && currop != edit.Eq) || (currop == edit.Del && prevop != edit.Eq) {
+			panic(fmt.Errorf("bad script: %v -> %v", prevop, currop))
+		}
+	}
+
+	return e, d.minimal, nil
+}
+
+// A differ holds the state for a single linear space diff.
+type differ struct {
+	ctx context.Context
+	ab  Pair
+
+	// vf and vb are the forward and reverse V arrays of Myers' paper.
+	// They are shared by all calls to middleSnake.
+	vf, vb []int
+	off    int
+
+	// iter counts middleSnake rounds, for cancellation checks.
+	iter uint
+
+	// maxCost is the number of rounds after which middleSnake gives up, if positive.
+	// minimal reports whether it has never given up.
+	maxCost int
+	minimal bool
+
+	// e is the edit script under construction.
+	// Consecutive deletions and insertions are accumulated
+	// in del and ins until the next run of equal elements.
+	e        edit.Script
+	del, ins edit.Range
+	inRun    bool
+}
+
+// compare diffs A[lowA:highA] against B[lowB:highB], appending the result to d.
+// It reports whether it completed; it returns false if d.ctx was cancelled.
+func (d *differ) compare(lowA, highA, lowB, highB int) bool {
+	// Strip the common prefix and suffix.
+	// They are always part of some optimal path,
+	// and doing so guarantees that the middle snake
+	// found below splits the problem.
+	x, y := lowA, lowB
+	for x < highA && y < highB && d.ab.Equal(x, y) {
+		x++
+		y++
+	}
+	d.eq(lowA, x, lowB, y)
+	lowA, lowB = x, y
+
+	u, v := highA, highB
+	for u > lowA && v > lowB && d.ab.Equal(u-1, v-1) {
+		u--
+		v--
+	}
+	suffix := highA - u
+	highA, highB = u, v
+
+	switch {
+	case lowA == highA && lowB == highB:
+		// nothing left
+	case lowA == highA:
+		d.insert(lowA, lowB, highB)
+	case lowB == highB:
+		d.delete(lowA, highA, lowB)
+	default:
+		x0, y0, x1, y1, ok := d.middleSnake(lowA, highA, lowB, highB)
+		if !ok {
+			return false
 		}
+		if !d.compare(lowA, x0, lowB, y0) {
+			return false
+		}
+		d.eq(x0, x1, y0, y1)
+		if !d.compare(x1, highA, y1, highB) {
+			return false
+		}
+	}
+
+	// Emit the common suffix.
+	d.eq(u, u+suffix, v, v+suffix)
+	return true
+}
 
-		// append the middle (populated) elements of v to trace
-		middle := v[max-d : max+d+1]
-		vcopy := make([]int, len(middle))
-		copy(vcopy, middle)
-		trace = append(trace, vcopy)
+// middleSnake finds the middle snake of an optimal path from
+// (lowA, lowB) to (highA, highB). The snake runs from (x0, y0) to (x1, y1).
+// It reports whether it succeeded; it fails only if d.ctx was cancelled.
+// If the search exceeds d.maxCost, the snake returned is empty,
+// and lies on some path that is not necessarily optimal.
+//
+// The caller must ensure that both ranges are non-empty.
+func (d *differ) middleSnake(lowA, highA, lowB, highB int) (x0, y0, x1, y1 int, ok bool) {
+	n := highA - lowA
+	m := highB - lowB
+	delta := n - m
+	odd := delta&1 != 0
+	vf, vb, off := d.vf, d.vb, d.off
+
+	// vf[off+k] is the furthest x reached on diagonal k = x-y going forwards.
+	// vb[off+k] is the furthest distance from the end reached on
+	// diagonal k = (n-x)-(m-y) going backwards.
+	vf[off+1] = 0
+	vb[off+1] = 0
+
+	for D := 0; D <= (n+m+1)/2; D++ {
+		// Only check context every 16th iteration to reduce overhead.
+		if d.ctx != nil && d.iter%16 == 0 && d.ctx.Err() != nil {
+			return 0, 0, 0, 0, false
+		}
+		d.iter++
 
-		for k := -d; k <= d; k += 2 {
+		// Forward pass.
+		for k := -D; k <= D; k += 2 {
 			var x int
-			if k == -d || (k != d && v[max+k-1] < v[max+k+1]) {
-				x = v[max+k+1]
+			if k == -D || (k != D && vf[off+k-1] < vf[off+k+1]) {
+				x = vf[off+k+1]
 			} else {
-				x = v[max+k-1] + 1
+				x = vf[off+k-1] + 1
 			}
-
 			y := x - k
-			for x < aLen && y < bLen && ab.Equal(x, y) {
+			sx, sy := x, y
+			for x < n && y < m && d.ab.Equal(lowA+x, lowB+y) {
+				x++
+				y++
+			}
+			vf[off+k] = x
+
+			if c := delta - k; odd && -(D-1) <= c && c <= D-1 && x+vb[off+c] >= n {
+				return lowA + sx, lowB + sy, lowA + x, lowB + y, true
+			}
+		}
+
+		// Backward pass.
+		for c := -D; c <= D; c += 2 {
+			var x int
+			if c == -D || (c != D && vb[off+c-1] < vb[off+c+1]) {
+				x = vb[off+c+1]
+			} else {
+				x = vb[off+c-1] + 1
+			}
+			y := x - c
+			sx, sy := x, y
+			for x < n && y < m && d.ab.Equal(highA-x-1, highB-y-1) {
 				x++
 				y++
 			}
-			v[max+k] = x
+			vb[off+c] = x
 
-			if x == aLen && y == bLen {
-				break search
+			if k := delta - c; !odd && -D <= k && k <= D && x+vf[off+k] >= n {
+				return highA - x, highB - y, highA - sx, highB - sy, true
 			}
 		}
+
+		if d.maxCost > 0 && D >= d.maxCost {
+			// Give up, and split at whichever of the furthest reaching
+			// forward and backward points has made the most progress.
+			d.minimal = false
+			fx, fy := d.furthest(vf, D, n, m)
+			bx, by := d.furthest(vb, D, n, m)
+			if fx+fy >= bx+by {
+				return lowA + fx, lowB + fy, lowA + fx, lowB + fy, true
+			}
+			return highA - bx, highB - by, highA - bx, highB - by, true
+		}
 	}
+	panic("myers: no middle snake found")
+}
 
-	if len(trace) == max {
-		// No commonality at all, delete everything and then insert everything.
-		// This is handled as a special case to avoid complicating the logic below.
-		return edit.NewScript(edit.Range{HighA: aLen}, edit.Range{HighB: bLen})
-	}
-
-	// Create reversed edit script.
-	x := aLen
-	y := bLen
-	var e edit.Script
-	for d := len(trace) - 1; d >= 0; d-- {
-		// v has indices -d .. 0 .. d
-		// access to elements of v have the form d + actual offset
-		v := trace[d]
-		k := x - y
-		var prevk int
-		if k == -d || (k != d && v[d+k-1] < v[d+k+1]) {
-			prevk = k + 1
-		} else {
-			prevk = k - 1
+// furthest returns the point reached in round D of a middleSnake search
+// with V array v that is furthest from its starting corner,
+// in a box of size n by m.
+// The point is relative to the starting corner.
+func (d *differ) furthest(v []int, D, n, m int) (x, y int) {
+	best := -1
+	for k := -D; k <= D; k += 2 {
+		// Diagonals may leave the box; clip them to it.
+		kx := v[d.off+k]
+		if kx > n {
+			kx = n
 		}
-		var prevx int
-		if idx := d + prevk; 0 <= idx && idx < len(v) {
-			prevx = v[idx]
+		ky := kx - k
+		if ky > m {
+			kx, ky = m+k, m
 		}
-		prevy := prevx - prevk
-		for x > prevx && y > prevy {
-			appendToReversed(&e, edit.Range{LowA: x - 1, LowB: y - 1, HighA: x, HighB: y})
-			x--
-			y--
+		if ky < 0 || kx < 0 {
+			continue
 		}
-		if d > 0 {
-			appendToReversed(&e, edit.Range{LowA: prevx, LowB: prevy, HighA: x, HighB: y})
+		if kx+ky > best {
+			best = kx + ky
+			x, y = kx, ky
 		}
-		x, y = prevx, prevy
 	}
+	return x, y
+}
 
-	// Reverse reversed edit script, to return to natural order.
-	reverse(e)
-
-	// Sanity check
-	for i := 1; i < len(e.Ranges); i++ {
-		prevop := e.Ranges[i-1].Op()
-		currop := e.Ranges[i].Op()
-		if (prevop == currop) || (prevop == edit.Ins && currop != edit.Eq) || (currop == edit.Del && prevop != edit.Eq) {
-			panic(fmt.Errorf("bad script: %v -> %v", prevop, currop))
+// eq records that A[lowA:highA] and B[lowB:highB] are equal.
+func (d *differ) eq(lowA, highA, lowB, highB int) {
+	if lowA == highA {
+		return
+	}
+	d.flush()
+	if n := len(d.e.Ranges); n > 0 {
+		if last := &d.e.Ranges[n-1]; last.IsEqual() && last.HighA == lowA && last.HighB == lowB {
+			last.HighA = highA
+			last.HighB = highB
+			return
 		}
 	}
+	d.e.Ranges = append(d.e.Ranges, edit.Range{LowA: lowA, HighA: highA, LowB: lowB, HighB: highB})
+}
 
-	return e
+// delete records that A[lowA:highA] was deleted at position b of B.
+func (d *differ) delete(lowA, highA, b int) {
+	d.pending(lowA, b)
+	d.del.HighA = highA
+	d.ins.LowA, d.ins.HighA = highA, highA
 }
 
-func reverse(e edit.Script) {
-	for i := 0; i < len(e.Ranges)/2; i++ {
-		j := len(e.Ranges) - i - 1
-		e.Ranges[i], e.Ranges[j] = e.Ranges[j], e.Ranges[i]
-	}
+// insert records that B[lowB:highB] was inserted at position a of A.
+func (d *differ) insert(a, lowB, highB int) {
+	d.pending(a, lowB)
+	d.ins.HighB = highB
 }
 
-func appendToReversed(e *edit.Script, seg edit.Range) {
-	if len(e.Ranges) == 0 {
-		e.Ranges = append(e.Ranges, seg)
+// pending starts a new run of deletions and insertions at (a, b),
+// unless one is already in progress.
+func (d *differ) pending(a, b int) {
+	if d.inRun {
 		return
 	}
-	u, ok := combineRanges(seg, e.Ranges[len(e.Ranges)-1])
-	if !ok {
-		e.Ranges = append(e.Ranges, seg)
+	d.del = edit.Range{LowA: a, HighA: a, LowB: b, HighB: b}
+	d.ins = d.del
+	d.inRun = true
+}
+
+// flush appends any pending deletions and insertions to d.e.
+// Deletions always precede insertions.
+func (d *differ) flush() {
+	if !d.inRun {
 		return
 	}
-	e.Ranges[len(e.Ranges)-1] = u
-	return
+	if d.del.Len() > 0 {
+		d.e.Ranges = append(d.e.Ranges, d.del)
+	}
+	if d.ins.Len() > 0 {
+		d.e.Ranges = append(d.e.Ranges, d.ins)
+	}
+	d.inRun = false
 }
 
-// combineRanges combines s and t into a single edit.Range if possible
-// and reports whether it succeeded.
-func combineRanges(s, t edit.Range) (u edit.Range, ok bool) {
-	if t.Len() == 0 {
-		return s, true
-	}
-	if s.Len() == 0 {
-		return t, true
-	}
-	if s.Op() != t.Op() {
-		return edit.Range{LowA: -1, HighA: -1, LowB: -1, HighB: -1}, false
-	}
-	switch s.Op() {
-	case edit.Ins:
-		s.HighB = t.HighB
-	case edit.Del:
-		s.HighA = t.HighA
-	case edit.Eq:
-		s.HighA = t.HighA
-		s.HighB = t.HighB
-	default:
-		panic("bad op")
-	}
-	return s, true
+// script returns the completed edit script.
+func (d *differ) script() edit.Script {
+	d.flush()
+	return d.e
 }
 
 func rangeString(r edit.Range) string {
@@ -2,10 +2,12 @@ package myers_test
 
 import (
 	"context"
+	"math/rand"
 	"reflect"
 	"testing"
 
 	"github.com/pkg/diff/edit"
+	"github.com/pkg/diff/internal/difftest"
 	"github.com/pkg/diff/myers"
 )
 
@@ -22,9 +24,10 @@ func TestMyers(t *testing.T) {
 			a:    "ABCABBA",
 			b:    "CBABAC",
 			want: []edit.Range{
-				{LowA: 0, HighA: 2, LowB: 0, HighB: 0},
-				{LowA: 2, HighA: 3, LowB: 0, HighB: 1},
-				{LowA: 3, HighA: 3, LowB: 1, HighB: 2},
+				{LowA: 0, HighA: 1, LowB: 0, HighB: 0},
+				{LowA: 1, HighA: 1, LowB: 0, HighB: 1},
+				{LowA: 1, HighA: 2, LowB: 1, HighB: 2},
+				{LowA: 2, HighA: 3, LowB: 2, HighB: 2},
 				{LowA: 3, HighA: 5, LowB: 2, HighB: 4},
 				{LowA: 5, HighA: 6, LowB: 4, HighB: 4},
 				{LowA: 6, HighA: 7, LowB: 4, HighB: 5},
@@ -57,7 +60,7 @@ func TestMyers(t *testing.T) {
 
 	for _, test := range tests {
 		t.Run(test.name, func(t *testing.T) {
-			ab := &diffByByte{a: test.a, b: test.b}
+			ab := &difftest.Bytes{A: test.a, B: test.b}
 			got := myers.Diff(context.Background(), ab)
 			want := edit.Script{Ranges: test.want}
 
@@ -77,10 +80,82 @@ func TestMyers(t *testing.T) {
 	}
 }
 
-type diffByByte struct {
-	a, b string
+func TestMyersMinimal(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	for i := 0; i < 1000; i++ {
+		a := difftest.RandString(rng, rng.Intn(20), "ABC")
+		b := difftest.RandString(rng, rng.Intn(20), "ABC")
+		ab := &difftest.Bytes{A: a, B: b}
+		e := myers.Diff(context.Background(), ab)
+		if err := difftest.CheckScript(a, b, e); err != nil {
+			t.Fatal(err)
+		}
+		ins, del := e.Stat()
+		if want := len(a) + len(b) - 2*lcs(a, b); ins+del != want {
+			t.Errorf("a=%q b=%q: got %d edits, want %d", a, b, ins+del, want)
+		}
+	}
 }
 
-func (ab *diffByByte) LenA() int             { return len(ab.a) }
-func (ab *diffByByte) LenB() int             { return len(ab.b) }
-func (ab *diffByByte) Equal(ai, bi int) bool { return ab.a[ai] == ab.b[bi] }
+func TestMyersLimit(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	for i := 0; i < 1000; i++ {
+		a := difftest.RandString(rng, rng.Intn(40), "ABCD")
+		b := difftest.RandString(rng, rng.Intn(40), "ABCD")
+		ab := &difftest.Bytes{A: a, B: b}
+		maxCost := 1 + rng.Intn(4)
+		e, minimal, err := myers.DiffLimit(context.Background(), ab, maxCost)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := difftest.CheckScript(a, b, e); err != nil {
+			t.Fatal(err)
+		}
+		ins, del := e.Stat()
+		optimal := len(a) + len(b) - 2*lcs(a, b)
+		if minimal && ins+del != optimal {
+			t.Errorf("a=%q b=%q maxCost=%d: reported minimal, but got %d edits, want %d", a, b, maxCost, ins+del, optimal)
+		}
+	}
+
+	// With a high enough limit, DiffLimit is the same as Diff.
+	ab := &difftest.Bytes{A: "ABCABBA", B: "CBABAC"}
+	e, minimal, err := myers.DiffLimit(context.Background(), ab, 100)
+	if want := myers.Diff(context.Background(), ab); err != nil || !minimal || !reflect.DeepEqual(e, want) {
+		t.Errorf("DiffLimit returned %v, %v, %v; want %v, true, nil", e, minimal, err, want)
+	}
+}
+
+func TestMyersCancel(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	ab := &difftest.Bytes{A: "ABCABBA", B: "CBABAC"}
+	e := myers.Diff(ctx, ab)
+	if len(e.Ranges) != 0 {
+		t.Errorf("got %v after cancellation, want empty script", e)
+	}
+	e, err := myers.DiffErr(ctx, ab)
+	if len(e.Ranges) != 0 || err != context.Canceled {
+		t.Errorf("DiffErr returned %v, %v after cancellation, want empty script, %v", e, err, context.Canceled)
+	}
+}
+
+// lcs returns the length of the longest common subsequence of a and b.
+func lcs(a, b string) int {
+	prev := make([]int, len(b)+1)
+	curr := make([]int, len(b)+1)
+	for i := range a {
+		for j := range b {
+			switch {
+			case a[i] == b[j]:
+				curr[j+1] = prev[j] + 1
+			case prev[j+1] > curr[j]:
+				curr[j+1] = prev[j+1]
+			default:
+				curr[j+1] = curr[j]
+			}
+		}
+		prev, curr = curr, prev
+	}
+	return prev[len(b)]
+}
@@ -0,0 +1,127 @@
+package diff
+
+import (
+	"context"
+
+	"github.com/pkg/diff/edit"
+	"github.com/pkg/diff/funcname"
+	"github.com/pkg/diff/myers"
+	"github.com/pkg/diff/write"
+)
+
+// An Algorithm calculates an edit script for ab.
+// myers.Diff, patience.Diff, and histogram.Diff are all Algorithms.
+type Algorithm func(ctx context.Context, ab myers.Pair) edit.Script
+
+// WithAlgorithm specifies the algorithm that Text and Slices use to calculate diffs.
+// The default is myers.Diff.
+//
+// WithAlgorithm is a write.Option so that it may be mixed with other write options,
+// but it is only meaningful to the functions in this package;
+// the functions in package write ignore it.
+func WithAlgorithm(algo Algorithm) write.Option {
+	return algorithmOpt{algo: algo}
+}
+
+type algorithmOpt struct {
+	write.ForeignOption
+	algo Algorithm
+}
+
+// ContextLines specifies that Text and Slices should preserve
+// n common lines (elements) of context around each change.
+// The default is 3. If n is negative, ContextLines panics.
+//
+// ContextLines is a write.Option so that it may be mixed with other write options,
+// but it is only meaningful to the functions in this package;
+// the functions in package write ignore it.
+func ContextLines(n int) write.Option {
+	if n < 0 {
+		panic("diff.ContextLines called with negative n")
+	}
+	return contextOpt{n: n}
+}
+
+type contextOpt struct {
+	write.ForeignOption
+	n int
+}
+
+// IgnoreCRAtEOL specifies that Text should ignore a carriage return
+// at the end of a line when comparing lines,
+// so that "\r\n" and "\n" line endings compare equal.
+// Lines are still written with their original line endings.
+//
+// IgnoreCRAtEOL is a write.Option so that it may be mixed with other write options,
+// but it is only meaningful to the functions in this package;
+// the functions in package write ignore it.
+func IgnoreCRAtEOL() write.Option {
+	return ignoreCROpt{}
+}
+
+type ignoreCROpt struct {
+	write.ForeignOption
+}
+
+// FuncNames specifies that Text should write a section header for each hunk,
+// containing the nearest line of a before the hunk that is matched by m,
+// as git does for its "@@ ... @@ func Foo" hunk headers.
+// For example, FuncNames(funcname.Go) names the enclosing Go function.
+//
+// FuncNames is a write.Option so that it may be mixed with other write options,
+// but it is only meaningful to the functions in this package;
+// the functions in package write ignore it.
+func FuncNames(m funcname.Matcher) write.Option {
+	return funcNamesOpt{m: m}
+}
+
+type funcNamesOpt struct {
+	write.ForeignOption
+	m funcname.Matcher
+}
+
+// config holds the settings used by Text and Slices.
+type config struct {
+	algo     Algorithm // nil means myers.DiffErr
+	context  int
+	ignoreCR bool
+	funcs    funcname.Matcher // nil means no section headers
+	write    []write.Option
+}
+
+// newConfig returns the config specified by options.
+// Options meaningful only to this package are removed from
+// the options passed through to the write package.
+func newConfig(options []write.Option) config {
+	c := config{context: 3}
+	for _, opt := range options {
+		switch opt := opt.(type) {
+		case algorithmOpt:
+			c.algo = opt.algo
+		case contextOpt:
+			c.context = opt.n
+		case ignoreCROpt:
+			c.ignoreCR = true
+		case funcNamesOpt:
+			c.funcs = opt.m
+		default:
+			c.write = append(c.write, opt)
+		}
+	}
+	return c
+}
+
+// diff calculates an edit script for ab using c's algorithm.
+// It returns ctx.Err() if ctx is cancelled before the diff is complete.
+func (c *config) diff(ctx context.Context, ab myers.Pair) (edit.Script, error) {
+	if c.algo == nil {
+		return myers.DiffErr(ctx, ab)
+	}
+	// Algorithms cannot report cancellation directly;
+	// an aborted diff looks like any other edit script.
+	s := c.algo(ctx, ab)
+	if err := ctx.Err(); err != nil {
+		return edit.Script{}, err
+	}
+	return s, nil
+}
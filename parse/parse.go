@@ -0,0 +1,403 @@
+// Package parse reads diffs in unified format,
+// such as those written by write.Unified, diff -u, and git diff.
+package parse
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/diff/edit"
+)
+
+// A File is the diff of a single file.
+type File struct {
+	// OldName and NewName are the names of the file before and after the change,
+	// as given by the "---" and "+++" lines, without any timestamp.
+	// Names are reported as they appear; prefixes such as git's "a/" and "b/" are not removed.
+	// A git diff with no "---" and "+++" lines, such as a pure rename,
+	// takes its names from its "diff --git" line.
+	OldName, NewName string
+	// Header holds the lines that precede the "---" line, without their newlines,
+	// starting with the "diff" line, if any.
+	// For a git diff, they include extended header lines
+	// such as "new file mode 100644" and "rename from old.go".
+	Header []string
+	// Hunks are the hunks of the diff, in order.
+	Hunks []*Hunk
+}
+
+// A Hunk is a single "@@" section of a unified diff.
+type Hunk struct {
+	// OldStart and OldLines are the line range of the hunk in the old file,
+	// and NewStart and NewLines the line range in the new file,
+	// exactly as written in the hunk header.
+	// Lines are numbered from 1. An empty range starts
+	// at the line before the hunk, so it may start at 0.
+	OldStart, OldLines int
+	NewStart, NewLines int
+	// Section is the text following the hunk header's closing "@@",
+	// such as the name of the enclosing function. It is usually empty.
+	Section string
+	// Lines are the lines of the hunk.
+	Lines []Line
+}
+
+// A Line is a single line of a hunk.
+type Line struct {
+	// Op is edit.Eq for a context line,
+	// edit.Del for a deleted line, and edit.Ins for an inserted line.
+	Op edit.Op
+	// Text is the text of the line, without its prefix or newline.
+	Text string
+	// NoNewline reports whether the line lacks a trailing newline,
+	// as indicated by a following "\ No newline at end of file" line.
+	NoNewline bool
+}
+
+// A SyntaxError reports a malformed diff.
+type SyntaxError struct {
+	Line int    // line number in the input, starting at 1
+	Msg  string // description of the problem
+}
+
+func (e *SyntaxError) Error() string {
+	return fmt.Sprintf("parse: line %d: %s", e.Line, e.Msg)
+}
+
+// Parse reads the unified diffs in r.
+// r may hold any number of file diffs, as produced by diff -ru or git diff.
+// Text outside of file diffs, such as a commit message, is ignored.
+// If the diffs are malformed, Parse returns a *SyntaxError.
+func Parse(r io.Reader) ([]*File, error) {
+	data, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	p := &parser{lines: splitLines(data)}
+	return p.parse()
+}
+
+// splitLines splits data into lines at each newline.
+// The newlines are not part of the lines.
+func splitLines(data []byte) []string {
+	var lines []string
+	for len(data) > 0 {
+		i := bytes.IndexByte(data, '\n')
+		if i < 0 {
+			lines = append(lines, string(data))
+			break
+		}
+		lines = append(lines, string(data[:i]))
+		data = data[i+1:]
+	}
+	return lines
+}
+
+// A parser holds the state of a single call to Parse.
+type parser struct {
+	lines []string
+	i     int // index of the next line
+}
+
+func (p *parser) errorf(format string, args ...interface{}) error {
+	return &SyntaxError{Line: p.i + 1, Msg: fmt.Sprintf(format, args...)}
+}
+
+func (p *parser) parse() ([]*File, error) {
+	var files []*File
+	// f is the file whose header is being read, if any.
+	var f *File
+	for p.i < len(p.lines) {
+		line := p.lines[p.i]
+		switch {
+		case strings.HasPrefix(line, "diff "):
+			// A diff line starts a new file,
+			// even if the previous one had no hunks.
+			if f != nil {
+				files = append(files, f)
+			}
+			f = &File{Header: []string{line}}
+			if strings.HasPrefix(line, "diff --git ") {
+				f.OldName, f.NewName = gitNames(line[len("diff --git "):])
+			}
+			p.i++
+		case strings.HasPrefix(line, "--- ") && p.i+1 < len(p.lines) && strings.HasPrefix(p.lines[p.i+1], "+++ "):
+			if f == nil {
+				f = new(File)
+			}
+			f.OldName = fileName(line[len("--- "):])
+			f.NewName = fileName(p.lines[p.i+1][len("+++ "):])
+			p.i += 2
+			if err := p.hunks(f); err != nil {
+				return nil, err
+			}
+			files = append(files, f)
+			f = nil
+		case strings.HasPrefix(line, "@@ "):
+			return nil, p.errorf("hunk without file header")
+		case f != nil:
+			f.Header = append(f.Header, line)
+			p.i++
+		default:
+			// Ignore text between file diffs.
+			p.i++
+		}
+	}
+	if f != nil {
+		files = append(files, f)
+	}
+	return files, nil
+}
+
+// fileName returns the file name in s, the remainder of a "---" or "+++" line.
+func fileName(s string) string {
+	// A tab separates the name from an optional timestamp.
+	if i := strings.IndexByte(s, '\t'); i >= 0 {
+		s = s[:i]
+	}
+	if name, ok := unquote(s); ok && len(name) > 0 {
+		return name
+	}
+	return s
+}
+
+// gitNames returns the old and new names in s, the remainder of a "diff --git" line.
+func gitNames(s string) (oldName, newName string) {
+	if q := quotedPrefix(s); q != "" {
+		oldName, _ = unquote(q)
+		s = strings.TrimPrefix(s[len(q):], " ")
+		if name, ok := unquote(s); ok {
+			return oldName, name
+		}
+		return oldName, s
+	}
+	if i := strings.Index(s, " \""); i >= 0 {
+		if name, ok := unquote(s[i+1:]); ok {
+			return s[:i], name
+		}
+	}
+	// Unquoted names may contain spaces, so the line is ambiguous.
+	// Git resolves this by requiring the old and new names to be the same
+	// after their prefixes, which is always true when there is no rename.
+	// Otherwise, split at the last " b/".
+	if n := len(s); n%2 == 1 && s[n/2] == ' ' {
+		a, b := s[:n/2], s[n/2+1:]
+		if i, j := strings.IndexByte(a, '/'), strings.IndexByte(b, '/'); i >= 0 && j >= 0 && a[i:] == b[j:] {
+			return a, b
+		}
+	}
+	if i := strings.LastIndex(s, " b/"); i >= 0 {
+		return s[:i], s[i+1:]
+	}
+	if i := strings.IndexByte(s, ' '); i >= 0 {
+		return s[:i], s[i+1:]
+	}
+	return s, s
+}
+
+// quotedPrefix returns the C-style quoted string at the start of s, if any.
+func quotedPrefix(s string) string {
+	if !strings.HasPrefix(s, `"`) {
+		return ""
+	}
+	for i := 1; i < len(s); i++ {
+		switch s[i] {
+		case '\\':
+			i++
+		case '"':
+			return s[:i+1]
+		}
+	}
+	return ""
+}
+
+// unquote unquotes s if it is a C-style quoted string,
+// as git uses for names that contain unusual characters.
+func unquote(s string) (string, bool) {
+	if len(s) < 2 || s[0] != '"' || s[len(s)-1] != '"' {
+		return "", false
+	}
+	name, err := strconv.Unquote(s)
+	if err != nil {
+		return "", false
+	}
+	return name, true
+}
+
+// hunks reads the hunks of f.
+func (p *parser) hunks(f *File) error {
+	var prev *Hunk
+	for p.i < len(p.lines) && strings.HasPrefix(p.lines[p.i], "@@ ") {
+		line := p.i + 1
+		h, err := p.hunk()
+		if err != nil {
+			return err
+		}
+		if prev != nil {
+			pa, pb := prev.end()
+			ha, hb := h.start()
+			if ha < pa || hb < pb {
+				return &SyntaxError{Line: line, Msg: "hunk overlaps previous hunk"}
+			}
+		}
+		f.Hunks = append(f.Hunks, h)
+		prev = h
+	}
+	return nil
+}
+
+// hunk reads a single hunk, starting with its header.
+func (p *parser) hunk() (*Hunk, error) {
+	h, err := parseHunkHeader(p.lines[p.i])
+	if err != nil {
+		return nil, p.errorf("%v", err)
+	}
+	p.i++
+	oldLeft, newLeft := h.OldLines, h.NewLines
+	for oldLeft > 0 || newLeft > 0 {
+		if p.i >= len(p.lines) {
+			return nil, p.errorf("unexpected end of hunk")
+		}
+		line := p.lines[p.i]
+		var l Line
+		if line == "" {
+			// Some tools strip the trailing space of an empty context line.
+			l.Op = edit.Eq
+		} else {
+			switch line[0] {
+			case ' ':
+				l.Op = edit.Eq
+			case '-':
+				l.Op = edit.Del
+			case '+':
+				l.Op = edit.Ins
+			case '\\':
+				if len(h.Lines) == 0 {
+					return nil, p.errorf("no newline marker at start of hunk")
+				}
+				h.Lines[len(h.Lines)-1].NoNewline = true
+				p.i++
+				continue
+			default:
+				return nil, p.errorf("unexpected end of hunk")
+			}
+			l.Text = line[1:]
+		}
+		if l.Op != edit.Ins {
+			oldLeft--
+		}
+		if l.Op != edit.Del {
+			newLeft--
+		}
+		if oldLeft < 0 || newLeft < 0 {
+			return nil, p.errorf("hunk has more lines than its header says")
+		}
+		h.Lines = append(h.Lines, l)
+		p.i++
+	}
+	// The last line of the hunk may lack a newline.
+	if p.i < len(p.lines) && strings.HasPrefix(p.lines[p.i], `\`) && len(h.Lines) > 0 {
+		h.Lines[len(h.Lines)-1].NoNewline = true
+		p.i++
+	}
+	return h, nil
+}
+
+// parseHunkHeader parses a hunk header of the form
+//
+//	@@ -oldStart,oldLines +newStart,newLines @@ section
+//
+// Either line count may be omitted, in which case it is 1.
+func parseHunkHeader(line string) (*Hunk, error) {
+	s := strings.TrimPrefix(line, "@@ ")
+	end := strings.Index(s, " @@")
+	if end < 0 {
+		return nil, fmt.Errorf("malformed hunk header %q", line)
+	}
+	fields := strings.Fields(s[:end])
+	if len(fields) != 2 || !strings.HasPrefix(fields[0], "-") || !strings.HasPrefix(fields[1], "+") {
+		return nil, fmt.Errorf("malformed hunk header %q", line)
+	}
+	h := new(Hunk)
+	var err error
+	if h.OldStart, h.OldLines, err = parseLineRange(fields[0][1:]); err != nil {
+		return nil, fmt.Errorf("malformed hunk header %q", line)
+	}
+	if h.NewStart, h.NewLines, err = parseLineRange(fields[1][1:]); err != nil {
+		return nil, fmt.Errorf("malformed hunk header %q", line)
+	}
+	h.Section = strings.TrimPrefix(s[end+len(" @@"):], " ")
+	return h, nil
+}
+
+// parseLineRange parses a line range of the form "start,lines" or "start".
+func parseLineRange(s string) (start, lines int, err error) {
+	lines = 1
+	if i := strings.IndexByte(s, ','); i >= 0 {
+		if lines, err = strconv.Atoi(s[i+1:]); err != nil {
+			return 0, 0, err
+		}
+		s = s[:i]
+	}
+	if start, err = strconv.Atoi(s); err != nil {
+		return 0, 0, err
+	}
+	if start < 0 || lines < 0 || (start == 0 && lines > 0) {
+		return 0, 0, fmt.Errorf("bad line range")
+	}
+	return start, lines, nil
+}
+
+// start returns the indices, starting at 0, of the first lines of h in A and B.
+func (h *Hunk) start() (a, b int) {
+	a, b = h.OldStart-1, h.NewStart-1
+	if h.OldLines == 0 {
+		a++
+	}
+	if h.NewLines == 0 {
+		b++
+	}
+	return a, b
+}
+
+// end returns the indices, starting at 0, of the lines following h in A and B.
+func (h *Hunk) end() (a, b int) {
+	a, b = h.start()
+	return a + h.OldLines, b + h.NewLines
+}
+
+// Script returns the edit script described by f's hunks.
+// Like a script whose context has been reduced by ctxt.Size,
+// it covers only the lines in the hunks;
+// the lines between hunks are implicitly equal.
+// Writing the script with write.Unified recreates the hunks.
+func (f *File) Script() edit.Script {
+	var e edit.Script
+	for _, h := range f.Hunks {
+		first := len(e.Ranges)
+		a, b := h.start()
+		for _, l := range h.Lines {
+			r := edit.Range{LowA: a, HighA: a, LowB: b, HighB: b}
+			if l.Op != edit.Ins {
+				a++
+				r.HighA = a
+			}
+			if l.Op != edit.Del {
+				b++
+				r.HighB = b
+			}
+			if n := len(e.Ranges); n > first {
+				if last := &e.Ranges[n-1]; last.Op() == l.Op {
+					last.HighA, last.HighB = r.HighA, r.HighB
+					continue
+				}
+			}
+			e.Ranges = append(e.Ranges, r)
+		}
+	}
+	return e
+}
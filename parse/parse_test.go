@@ -0,0 +1,320 @@
+package parse_test
+
+import (
+	"bytes"
+	"io"
+	"io/ioutil"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/pkg/diff"
+	"github.com/pkg/diff/edit"
+	"github.com/pkg/diff/parse"
+	"github.com/pkg/diff/write"
+)
+
+// hunkLines is a write.NewlinePair holding the lines of a parsed file's hunks.
+type hunkLines struct {
+	a, b map[int]parse.Line
+}
+
+func newHunkLines(f *parse.File) *hunkLines {
+	ab := &hunkLines{a: make(map[int]parse.Line), b: make(map[int]parse.Line)}
+	e := f.Script()
+	var lines []parse.Line
+	for _, h := range f.Hunks {
+		lines = append(lines, h.Lines...)
+	}
+	for _, r := range e.Ranges {
+		for ai := r.LowA; ai < r.HighA; ai++ {
+			ab.a[ai], lines = lines[0], lines[1:]
+			if r.IsEqual() {
+				ab.b[r.LowB+ai-r.LowA] = ab.a[ai]
+			}
+		}
+		if r.IsInsert() {
+			for bi := r.LowB; bi < r.HighB; bi++ {
+				ab.b[bi], lines = lines[0], lines[1:]
+			}
+		}
+	}
+	return ab
+}
+
+func (ab *hunkLines) WriteATo(w io.Writer, ai int) (int, error) {
+	return io.WriteString(w, ab.a[ai].Text)
+}
+func (ab *hunkLines) WriteBTo(w io.Writer, bi int) (int, error) {
+	return io.WriteString(w, ab.b[bi].Text)
+}
+func (ab *hunkLines) NoNewlineA(ai int) bool { return ab.a[ai].NoNewline }
+func (ab *hunkLines) NoNewlineB(bi int) bool { return ab.b[bi].NoNewline }
+
+func TestRoundTrip(t *testing.T) {
+	golden, err := ioutil.ReadFile("../testdata/rewriteAMD64.go.out")
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name string
+		diff string
+	}{
+		{name: "Golden", diff: string(golden)},
+		{name: "Empty", diff: "--- a\n+++ b\n"},
+		{name: "Insert", diff: text(t, "a\nb\n", "a\nx\nb\n")},
+		{name: "FromEmpty", diff: text(t, "", "a\nb\n")},
+		{name: "ToEmpty", diff: text(t, "a\nb\n", "")},
+		{name: "NoNewline", diff: text(t, "a\nb", "a\nc")},
+		{name: "AddNewline", diff: text(t, "a\nb", "a\nb\n")},
+		{name: "EmptyLines", diff: text(t, "\n\n\n", "\nx\n\n\n")},
+		{name: "Hunks", diff: text(t, "1\n2\n3\n4\n5\n6\n7\n8\n9\n10\n", "x\n2\n3\n4\n5\n6\n7\n8\n9\ny\n")},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			files, err := parse.Parse(strings.NewReader(test.diff))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(files) != 1 {
+				t.Fatalf("got %d files, want 1", len(files))
+			}
+			f := files[0]
+			got := new(bytes.Buffer)
+			err = write.Unified(f.Script(), got, newHunkLines(f), write.Names(f.OldName, f.NewName))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got.String() != test.diff {
+				t.Errorf("round trip of\n%s\ngot\n%s", test.diff, got)
+			}
+		})
+	}
+}
+
+// text returns the unified diff of a and b.
+func text(t *testing.T, a, b string) string {
+	buf := new(bytes.Buffer)
+	if err := diff.Text("a", "b", a, b, buf); err != nil {
+		t.Fatal(err)
+	}
+	return buf.String()
+}
+
+const gitPatch = `From 1234567 Mon Sep 17 00:00:00 2001
+Subject: [PATCH] Change some files
+
+---
+ a.txt | 2 +-
+ 1 file changed
+
+diff --git a/a.txt b/a.txt
+index 7898192..6178079 100644
+--- a/a.txt
++++ b/a.txt
+@@ -1,3 +1,3 @@ func f()
+ a
+-b
++c
+ d
+@@ -10 +10,2 @@
+ x
++y
+\ No newline at end of file
+diff --git a/new file.txt b/new file.txt
+new file mode 100644
+index 0000000..e69de29
+--- /dev/null
++++ b/new file.txt
+@@ -0,0 +1 @@
++new
+diff --git a/gone.txt b/gone.txt
+deleted file mode 100644
+index 7898192..0000000
+--- a/gone.txt
++++ /dev/null
+@@ -1 +0,0 @@
+-gone
+diff --git a/old.go b/new.go
+similarity index 100%
+rename from old.go
+rename to new.go
+diff --git a/img.png b/img.png
+index 1111111..2222222 100644
+Binary files a/img.png and b/img.png differ
+diff --git "a/tab\there" "b/tab\there"
+old mode 100644
+new mode 100755
+--
+2.30.0
+`
+
+func TestGit(t *testing.T) {
+	files, err := parse.Parse(strings.NewReader(gitPatch))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []*parse.File{
+		{
+			OldName: "a/a.txt",
+			NewName: "b/a.txt",
+			Header:  []string{"diff --git a/a.txt b/a.txt", "index 7898192..6178079 100644"},
+			Hunks: []*parse.Hunk{
+				{
+					OldStart: 1, OldLines: 3, NewStart: 1, NewLines: 3,
+					Section: "func f()",
+					Lines: []parse.Line{
+						{Op: edit.Eq, Text: "a"},
+						{Op: edit.Del, Text: "b"},
+						{Op: edit.Ins, Text: "c"},
+						{Op: edit.Eq, Text: "d"},
+					},
+				},
+				{
+					OldStart: 10, OldLines: 1, NewStart: 10, NewLines: 2,
+					Lines: []parse.Line{
+						{Op: edit.Eq, Text: "x"},
+						{Op: edit.Ins, Text: "y", NoNewline: true},
+					},
+				},
+			},
+		},
+		{
+			OldName: "/dev/null",
+			NewName: "b/new file.txt",
+			Header:  []string{"diff --git a/new file.txt b/new file.txt", "new file mode 100644", "index 0000000..e69de29"},
+			Hunks: []*parse.Hunk{
+				{OldStart: 0, OldLines: 0, NewStart: 1, NewLines: 1, Lines: []parse.Line{{Op: edit.Ins, Text: "new"}}},
+			},
+		},
+		{
+			OldName: "a/gone.txt",
+			NewName: "/dev/null",
+			Header:  []string{"diff --git a/gone.txt b/gone.txt", "deleted file mode 100644", "index 7898192..0000000"},
+			Hunks: []*parse.Hunk{
+				{OldStart: 1, OldLines: 1, NewStart: 0, NewLines: 0, Lines: []parse.Line{{Op: edit.Del, Text: "gone"}}},
+			},
+		},
+		{
+			OldName: "a/old.go",
+			NewName: "b/new.go",
+			Header:  []string{"diff --git a/old.go b/new.go", "similarity index 100%", "rename from old.go", "rename to new.go"},
+		},
+		{
+			OldName: "a/img.png",
+			NewName: "b/img.png",
+			Header:  []string{"diff --git a/img.png b/img.png", "index 1111111..2222222 100644", "Binary files a/img.png and b/img.png differ"},
+		},
+		{
+			OldName: "a/tab\there",
+			NewName: "b/tab\there",
+			Header:  []string{`diff --git "a/tab\there" "b/tab\there"`, "old mode 100644", "new mode 100755", "--", "2.30.0"},
+		},
+	}
+	if len(files) != len(want) {
+		t.Fatalf("got %d files, want %d", len(files), len(want))
+	}
+	for i := range want {
+		if !reflect.DeepEqual(files[i], want[i]) {
+			t.Errorf("file %d:\ngot  %+v\nwant %+v", i, files[i], want[i])
+		}
+	}
+
+	wantScript := edit.NewScript(
+		edit.Range{LowA: 0, HighA: 1, LowB: 0, HighB: 1},
+		edit.Range{LowA: 1, HighA: 2, LowB: 1, HighB: 1},
+		edit.Range{LowA: 2, HighA: 2, LowB: 1, HighB: 2},
+		edit.Range{LowA: 2, HighA: 3, LowB: 2, HighB: 3},
+		edit.Range{LowA: 9, HighA: 10, LowB: 9, HighB: 10},
+		edit.Range{LowA: 10, HighA: 10, LowB: 10, HighB: 11},
+	)
+	if got := files[0].Script(); !reflect.DeepEqual(got, wantScript) {
+		t.Errorf("Script() = %v, want %v", got, wantScript)
+	}
+}
+
+func TestGitNames(t *testing.T) {
+	tests := []struct {
+		line             string
+		oldName, newName string
+	}{
+		{line: "diff --git a/x b/x", oldName: "a/x", newName: "b/x"},
+		{line: "diff --git a/x y b/x y", oldName: "a/x y", newName: "b/x y"},
+		{line: "diff --git a/x b/y z", oldName: "a/x", newName: "b/y z"},
+		{line: `diff --git "a/\303\251" "b/\303\251"`, oldName: "a/é", newName: "b/é"},
+		{line: `diff --git a/x "b/\"y\""`, oldName: "a/x", newName: `b/"y"`},
+	}
+	for _, test := range tests {
+		files, err := parse.Parse(strings.NewReader(test.line + "\n"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(files) != 1 {
+			t.Fatalf("%s: got %d files, want 1", test.line, len(files))
+		}
+		if f := files[0]; f.OldName != test.oldName || f.NewName != test.newName {
+			t.Errorf("%s: got names %q, %q, want %q, %q", test.line, f.OldName, f.NewName, test.oldName, test.newName)
+		}
+	}
+}
+
+func TestGNU(t *testing.T) {
+	// Output of diff -ru, which omits line counts of 1
+	// and includes timestamps.
+	const patch = `Only in a: extra
+diff -ru a/x.txt b/x.txt
+--- a/x.txt	2020-01-01 00:00:00.000000000 +0000
++++ b/x.txt	2020-01-02 00:00:00.000000000 +0000
+@@ -1 +1 @@
+-old
++new
+diff -ru a/y.txt b/y.txt
+--- a/y.txt	2020-01-01 00:00:00.000000000 +0000
++++ b/y.txt	2020-01-02 00:00:00.000000000 +0000
+@@ -2,0 +3 @@
++add
+`
+	files, err := parse.Parse(strings.NewReader(patch))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(files) != 2 {
+		t.Fatalf("got %d files, want 2", len(files))
+	}
+	if f := files[0]; f.OldName != "a/x.txt" || f.NewName != "b/x.txt" || len(f.Header) != 1 {
+		t.Errorf("file 0 = %+v", f)
+	}
+	want := edit.NewScript(edit.Range{LowA: 2, HighA: 2, LowB: 2, HighB: 3})
+	if got := files[1].Script(); !reflect.DeepEqual(got, want) {
+		t.Errorf("Script() = %v, want %v", got, want)
+	}
+}
+
+func TestSyntaxError(t *testing.T) {
+	tests := []struct {
+		name  string
+		patch string
+		line  int
+	}{
+		{name: "NoFileHeader", patch: "@@ -1 +1 @@\n-a\n+b\n", line: 1},
+		{name: "BadHeader", patch: "--- a\n+++ b\n@@ -1 @@\n-a\n", line: 3},
+		{name: "BadNumber", patch: "--- a\n+++ b\n@@ -x +1 @@\n-a\n", line: 3},
+		{name: "Short", patch: "--- a\n+++ b\n@@ -1,2 +1,2 @@\n a\n", line: 5},
+		{name: "Long", patch: "--- a\n+++ b\n@@ -1 +1,2 @@\n-a\n-b\n+c\n", line: 5},
+		{name: "Garbage", patch: "--- a\n+++ b\n@@ -1,2 +1,2 @@\n a\n?b\n", line: 5},
+		{name: "Overlap", patch: "--- a\n+++ b\n@@ -1,2 +1,2 @@\n a\n b\n@@ -2 +2 @@\n b\n", line: 6},
+		{name: "Marker", patch: "--- a\n+++ b\n@@ -1 +1 @@\n\\ No newline at end of file\n a\n", line: 4},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := parse.Parse(strings.NewReader(test.patch))
+			serr, ok := err.(*parse.SyntaxError)
+			if !ok {
+				t.Fatalf("got error %v, want *SyntaxError", err)
+			}
+			if serr.Line != test.line {
+				t.Errorf("got error at line %d, want %d: %v", serr.Line, test.line, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,239 @@
+// Package patch applies diffs.
+package patch
+
+import (
+	"bytes"
+	"fmt"
+
+	"github.com/pkg/diff/edit"
+	"github.com/pkg/diff/parse"
+)
+
+// An Option modifies behavior when applying a diff.
+type Option interface {
+	isOption()
+}
+
+// Fuzz specifies the maximum fuzz factor used to match hunks, as with patch -F.
+// With a fuzz factor of n, up to n lines of leading and trailing context
+// may be ignored when a hunk does not match exactly.
+// The default is 2. If n is negative, Fuzz panics.
+func Fuzz(n int) Option {
+	if n < 0 {
+		panic("patch.Fuzz called with negative n")
+	}
+	return fuzzOpt(n)
+}
+
+type fuzzOpt int
+
+func (fuzzOpt) isOption() {}
+
+// A Reject is a hunk that could not be applied.
+type Reject struct {
+	Index int         // index of the hunk in File.Hunks
+	Hunk  *parse.Hunk // the hunk
+}
+
+// A RejectError reports the hunks of a diff that could not be applied.
+type RejectError struct {
+	Name    string   // name of the patched file, from File.NewName
+	Rejects []Reject // rejected hunks, in order
+}
+
+func (e *RejectError) Error() string {
+	return fmt.Sprintf("patch: %s: %d hunk(s) rejected", e.Name, len(e.Rejects))
+}
+
+// A line is a single line of text.
+type line struct {
+	text      string // without its newline
+	noNewline bool   // whether the line lacks a trailing newline
+}
+
+// splitLines splits text into lines at each newline, as diff.Text does.
+func splitLines(text []byte) []line {
+	var lines []line
+	for len(text) > 0 {
+		i := bytes.IndexByte(text, '\n')
+		if i < 0 {
+			lines = append(lines, line{text: string(text), noNewline: true})
+			break
+		}
+		lines = append(lines, line{text: string(text[:i])})
+		text = text[i+1:]
+	}
+	return lines
+}
+
+// joinLines joins lines into text.
+func joinLines(lines []line) []byte {
+	buf := new(bytes.Buffer)
+	for i, l := range lines {
+		buf.WriteString(l.text)
+		if !l.noNewline || i < len(lines)-1 {
+			buf.WriteByte('\n')
+		}
+	}
+	return buf.Bytes()
+}
+
+// Apply applies the hunks of f to a and returns the result.
+//
+// Like GNU patch, Apply tolerates a diff made against a slightly different a.
+// If a hunk does not match at the line given in its header,
+// Apply searches for the nearest place where it does match,
+// and later hunks are adjusted by the same offset.
+// If a hunk does not match anywhere, Apply tries again ignoring
+// up to the fuzz factor (see Fuzz) lines of leading and trailing context.
+// Hunks never overlap, and are applied in order.
+//
+// If some hunks cannot be applied, Apply applies the rest,
+// and returns the result along with a *RejectError.
+func Apply(a []byte, f *parse.File, options ...Option) ([]byte, error) {
+	fuzz := 2
+	for _, opt := range options {
+		switch opt := opt.(type) {
+		case fuzzOpt:
+			fuzz = int(opt)
+		}
+	}
+
+	src := splitLines(a)
+	var dst []line
+	var rejects []Reject
+	pos := 0    // index in src of the first line not yet copied to dst
+	offset := 0 // offset at which the last hunk applied
+	for i, h := range f.Hunks {
+		before, after, lead, trail := hunkLines(h)
+		start := h.OldStart - 1
+		if h.OldLines == 0 {
+			start++
+		}
+		applied := false
+		for n := 0; n <= fuzz && !applied; n++ {
+			cutLead, cutTrail := min(n, lead), min(n, trail)
+			if n > lead && n > trail {
+				// No more context to ignore.
+				break
+			}
+			pattern := before[cutLead : len(before)-cutTrail]
+			want := start + offset + cutLead
+			at, ok := search(src, pattern, want, pos)
+			if !ok {
+				continue
+			}
+			dst = append(dst, src[pos:at]...)
+			dst = append(dst, after[cutLead:len(after)-cutTrail]...)
+			pos = at + len(pattern)
+			offset = at - cutLead - start
+			applied = true
+		}
+		if !applied {
+			rejects = append(rejects, Reject{Index: i, Hunk: h})
+		}
+	}
+	dst = append(dst, src[pos:]...)
+
+	b := joinLines(dst)
+	if len(rejects) > 0 {
+		return b, &RejectError{Name: f.NewName, Rejects: rejects}
+	}
+	return b, nil
+}
+
+// hunkLines returns the lines of h before and after the change,
+// and the number of leading and trailing context lines.
+func hunkLines(h *parse.Hunk) (before, after []line, lead, trail int) {
+	for _, l := range h.Lines {
+		x := line{text: l.Text, noNewline: l.NoNewline}
+		if l.Op != edit.Ins {
+			before = append(before, x)
+		}
+		if l.Op != edit.Del {
+			after = append(after, x)
+		}
+	}
+	for lead < len(h.Lines) && h.Lines[lead].Op == edit.Eq {
+		lead++
+	}
+	for trail < len(h.Lines)-lead && h.Lines[len(h.Lines)-1-trail].Op == edit.Eq {
+		trail++
+	}
+	return before, after, lead, trail
+}
+
+// search returns the index in src closest to want at which pattern occurs,
+// ignoring any occurrence that starts before low.
+func search(src, pattern []line, want, low int) (int, bool) {
+	high := len(src) - len(pattern)
+	if high < low {
+		return 0, false
+	}
+	if want < low {
+		want = low
+	}
+	if want > high {
+		want = high
+	}
+	for d := 0; want-d >= low || want+d <= high; d++ {
+		if at := want - d; at >= low && match(src[at:], pattern) {
+			return at, true
+		}
+		if at := want + d; d > 0 && at <= high && match(src[at:], pattern) {
+			return at, true
+		}
+	}
+	return 0, false
+}
+
+// match reports whether src starts with pattern.
+// Whether the last line lacks a newline is not significant.
+func match(src, pattern []line) bool {
+	for i, l := range pattern {
+		if src[i].text != l.text {
+			return false
+		}
+	}
+	return true
+}
+
+func min(x, y int) int {
+	if x < y {
+		return x
+	}
+	return y
+}
+
+// ApplyScript applies e to a and returns the result,
+// taking inserted lines from b.
+// a and b are split into lines as by diff.Text,
+// and e is typically an edit script calculated for them by diff.Text.
+//
+// e may have had its context reduced, as by ctxt.Size;
+// lines not covered by e are copied from a.
+// ApplyScript reports an error if e is not a well-formed edit script from a to b,
+// as reported by Script.Validate, but it does not check that lines of a and b that e says are equal are in fact equal.
+func ApplyScript(a, b []byte, e edit.Script) ([]byte, error) {
+	src, ins := splitLines(a), splitLines(b)
+	if err := e.Validate(len(src), len(ins)); err != nil {
+		return nil, fmt.Errorf("patch: %v", err)
+	}
+	// Normalizing e splits replacements and moves any insertion
+	// anchored at the start of the preceding deletion to its end,
+	// so that its ranges are in order in both a and b.
+	var dst []line
+	ai := 0 // index in src of the first line not yet processed
+	for _, r := range e.Normalize().Ranges {
+		dst = append(dst, src[ai:r.LowA]...)
+		switch r.Op() {
+		case edit.Eq:
+			dst = append(dst, src[r.LowA:r.HighA]...)
+		case edit.Ins:
+			dst = append(dst, ins[r.LowB:r.HighB]...)
+		}
+		ai = r.HighA
+	}
+	dst = append(dst, src[ai:]...)
+	return joinLines(dst), nil
+}
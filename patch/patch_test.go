@@ -0,0 +1,272 @@
+package patch_test
+
+import (
+	"bytes"
+	"context"
+	"io/ioutil"
+	"strings"
+	"testing"
+
+	"github.com/pkg/diff"
+	"github.com/pkg/diff/ctxt"
+	"github.com/pkg/diff/myers"
+	"github.com/pkg/diff/parse"
+	"github.com/pkg/diff/patch"
+)
+
+// parseOne parses a diff of a single file.
+func parseOne(t *testing.T, d string) *parse.File {
+	t.Helper()
+	files, err := parse.Parse(strings.NewReader(d))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(files) != 1 {
+		t.Fatalf("got %d files, want 1", len(files))
+	}
+	return files[0]
+}
+
+var roundTripTests = []struct {
+	name string
+	a, b string
+}{
+	{name: "Same", a: "a\nb\n", b: "a\nb\n"},
+	{name: "FromEmpty", a: "", b: "a\nb\n"},
+	{name: "ToEmpty", a: "a\nb\n", b: ""},
+	{name: "Change", a: "a\nb\nc\n", b: "a\nx\nc\n"},
+	{name: "NoNewline", a: "a\nb", b: "a\nc"},
+	{name: "AddNewline", a: "a\nb", b: "a\nb\n"},
+	{name: "RemoveNewline", a: "a\nb\n", b: "a\nb"},
+	{name: "Hunks", a: "1\n2\n3\n4\n5\n6\n7\n8\n9\n10\n", b: "x\n2\n3\n4\n5\n6\n7\n8\n9\ny\n"},
+	{name: "CRLF", a: "a\r\nb\r\n", b: "a\r\nc\r\n"},
+	{name: "NothingInCommon", a: "x\ny\n", b: "p\nq\nr\n"},
+}
+
+func TestApplyRoundTrip(t *testing.T) {
+	for _, test := range roundTripTests {
+		t.Run(test.name, func(t *testing.T) {
+			d := new(bytes.Buffer)
+			if err := diff.Text("a", "b", test.a, test.b, d); err != nil {
+				t.Fatal(err)
+			}
+			got, err := patch.Apply([]byte(test.a), parseOne(t, d.String()))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(got) != test.b {
+				t.Errorf("Apply() = %q, want %q", got, test.b)
+			}
+		})
+	}
+}
+
+func TestApplyGolden(t *testing.T) {
+	a, err := ioutil.ReadFile("../testdata/rewriteAMD64.go.a")
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := ioutil.ReadFile("../testdata/rewriteAMD64.go.b")
+	if err != nil {
+		t.Fatal(err)
+	}
+	d, err := ioutil.ReadFile("../testdata/rewriteAMD64.go.out")
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := patch.Apply(a, parseOne(t, string(d)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, b) {
+		t.Errorf("applying golden diff did not reproduce %s", "rewriteAMD64.go.b")
+	}
+}
+
+const fuzzyDiff = `--- a
++++ b
+@@ -2,5 +2,5 @@
+ c1
+ c2
+-old
++new
+ c3
+ c4
+`
+
+func TestApplyFuzzy(t *testing.T) {
+	tests := []struct {
+		name    string
+		a       string
+		options []patch.Option
+		want    string
+		rejects []int
+	}{
+		{
+			name: "Exact",
+			a:    "x\nc1\nc2\nold\nc3\nc4\ny\n",
+			want: "x\nc1\nc2\nnew\nc3\nc4\ny\n",
+		},
+		{
+			name: "OffsetDown",
+			a:    "x\nx\nx\nc1\nc2\nold\nc3\nc4\n",
+			want: "x\nx\nx\nc1\nc2\nnew\nc3\nc4\n",
+		},
+		{
+			name: "OffsetUp",
+			a:    "c1\nc2\nold\nc3\nc4\n",
+			want: "c1\nc2\nnew\nc3\nc4\n",
+		},
+		{
+			name: "Nearest",
+			a:    "c1\nc2\nold\nc3\nc4\nx\nx\nx\nx\nc1\nc2\nold\nc3\nc4\n",
+			want: "c1\nc2\nnew\nc3\nc4\nx\nx\nx\nx\nc1\nc2\nold\nc3\nc4\n",
+		},
+		{
+			name: "Fuzz1",
+			a:    "x\nC1\nc2\nold\nc3\nC4\n",
+			want: "x\nC1\nc2\nnew\nc3\nC4\n",
+		},
+		{
+			name: "Fuzz2",
+			a:    "x\nC1\nC2\nold\nC3\nC4\n",
+			want: "x\nC1\nC2\nnew\nC3\nC4\n",
+		},
+		{
+			name:    "Fuzz0",
+			a:       "x\nC1\nc2\nold\nc3\nc4\n",
+			options: []patch.Option{patch.Fuzz(0)},
+			want:    "x\nC1\nc2\nold\nc3\nc4\n",
+			rejects: []int{0},
+		},
+		{
+			name:    "Missing",
+			a:       "x\nc1\nc2\nOLD\nc3\nc4\n",
+			want:    "x\nc1\nc2\nOLD\nc3\nc4\n",
+			rejects: []int{0},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got, err := patch.Apply([]byte(test.a), parseOne(t, fuzzyDiff), test.options...)
+			if string(got) != test.want {
+				t.Errorf("Apply() = %q, want %q", got, test.want)
+			}
+			checkRejects(t, err, test.rejects)
+		})
+	}
+}
+
+func TestApplyPartial(t *testing.T) {
+	const d = `--- a
++++ b
+@@ -1,3 +1,3 @@
+ a
+-b
++B
+ c
+@@ -5,3 +5,3 @@
+ e
+-f
++F
+ g
+@@ -9,3 +9,3 @@
+ i
+-j
++J
+ k
+`
+	// The second hunk no longer applies, and the third has moved down a line.
+	a := "a\nb\nc\nd\ne\nx\ng\nh\nh\ni\nj\nk\n"
+	want := "a\nB\nc\nd\ne\nx\ng\nh\nh\ni\nJ\nk\n"
+	got, err := patch.Apply([]byte(a), parseOne(t, d))
+	if string(got) != want {
+		t.Errorf("Apply() = %q, want %q", got, want)
+	}
+	checkRejects(t, err, []int{1})
+}
+
+func checkRejects(t *testing.T, err error, want []int) {
+	t.Helper()
+	if len(want) == 0 {
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		return
+	}
+	rerr, ok := err.(*patch.RejectError)
+	if !ok {
+		t.Fatalf("got error %v, want *RejectError", err)
+	}
+	if rerr.Name != "b" {
+		t.Errorf("RejectError.Name = %q, want %q", rerr.Name, "b")
+	}
+	var got []int
+	for _, r := range rerr.Rejects {
+		got = append(got, r.Index)
+	}
+	if len(got) != len(want) {
+		t.Fatalf("rejected hunks %v, want %v", got, want)
+	}
+	for i := range got {
+		if got[i] != want[i] {
+			t.Fatalf("rejected hunks %v, want %v", got, want)
+		}
+	}
+}
+
+// lines is a myers.Pair of lines, split as by diff.Text.
+type lines struct {
+	a, b []string
+}
+
+func splitLines(s string) []string {
+	l := strings.SplitAfter(s, "\n")
+	if l[len(l)-1] == "" {
+		l = l[:len(l)-1]
+	}
+	return l
+}
+
+func (ab *lines) LenA() int             { return len(ab.a) }
+func (ab *lines) LenB() int             { return len(ab.b) }
+func (ab *lines) Equal(ai, bi int) bool { return ab.a[ai] == ab.b[bi] }
+
+func TestApplyScript(t *testing.T) {
+	for _, test := range roundTripTests {
+		t.Run(test.name, func(t *testing.T) {
+			// Comparing lines with their newlines,
+			// a final line without a newline is not equal
+			// to one with a newline, just as in diff.Text.
+			ab := &lines{a: splitLines(test.a), b: splitLines(test.b)}
+			e := myers.Diff(context.Background(), ab)
+			for _, n := range []int{0, 3} {
+				got, err := patch.ApplyScript([]byte(test.a), []byte(test.b), ctxt.Size(e, n))
+				if err != nil {
+					t.Fatal(err)
+				}
+				if string(got) != test.b {
+					t.Errorf("ApplyScript(ctxt.Size(e, %d)) = %q, want %q", n, got, test.b)
+				}
+			}
+			got, err := patch.ApplyScript([]byte(test.a), []byte(test.b), e.JoinReplacements())
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(got) != test.b {
+				t.Errorf("ApplyScript(e.JoinReplacements()) = %q, want %q", got, test.b)
+			}
+		})
+	}
+}
+
+func TestApplyScriptError(t *testing.T) {
+	ab := &lines{a: splitLines("a\nb\n"), b: splitLines("a\nc\nd\n")}
+	e := myers.Diff(context.Background(), ab)
+	if _, err := patch.ApplyScript([]byte("a\nb\n"), []byte("a\nc\n"), e); err == nil {
+		t.Errorf("ApplyScript with short b succeeded")
+	}
+	if _, err := patch.ApplyScript([]byte("a\nb\nx\n"), []byte("a\nc\nd\n"), e); err == nil {
+		t.Errorf("ApplyScript with long a succeeded")
+	}
+}
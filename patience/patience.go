@@ -0,0 +1,200 @@
+// Package patience implements the patience diff algorithm.
+//
+// Patience diff anchors the diff on elements that occur exactly once
+// in both A and B, which tends to produce more readable diffs of
+// source code than the Myers algorithm, because it does not align
+// unrelated common elements like braces and blank lines.
+package patience
+
+import (
+	"context"
+	"sort"
+
+	"github.com/pkg/diff/edit"
+	"github.com/pkg/diff/internal/build"
+	"github.com/pkg/diff/internal/pairs"
+	"github.com/pkg/diff/myers"
+)
+
+// Diff calculates an edit.Script for ab using the patience diff algorithm.
+// Regions of ab that contain no unique common elements are diffed
+// using myers.Diff.
+//
+// Patience diff needs to know which elements of A are equal to each other.
+// If ab has methods
+//
+//	KeyA(ai int) interface{}
+//	KeyB(bi int) interface{}
+//
+// returning comparable values that are equal exactly when the elements are equal,
+// Diff uses them to group elements in linear time.
+// Otherwise, grouping elements would take quadratic time,
+// so Diff uses myers.Diff instead.
+//
+// With keys, Diff then slides each run of changes over equal elements
+// as git does, to line it up with changes in the other side where possible.
+// The result matches git diff --patience --no-indent-heuristic,
+// except in regions with no unique common elements,
+// which git diffs with its own Myers implementation
+// and so may place changes differently.
+// git's default indent heuristic is not applied,
+// because Diff does not see the text of the elements.
+//
+// Because diff calculation can be expensive, Diff supports cancellation via ctx.
+// If ctx is cancelled, Diff returns an empty edit.Script.
+func Diff(ctx context.Context, ab myers.Pair) edit.Script {
+	d := &differ{ctx: ctx, ab: ab}
+	if k, keyed := ab.(pairs.Keyer); keyed {
+		d.classA, d.classB = pairs.Classes(ab.LenA(), ab.LenB(), k)
+		if !d.diff(0, ab.LenA(), 0, ab.LenB()) {
+			return edit.Script{}
+		}
+		return pairs.Compact(pairs.Script(&d.s), ab.LenA(), ab.LenB(), k)
+	}
+	if !d.fallback(0, ab.LenA(), 0, ab.LenB()) {
+		return edit.Script{}
+	}
+	return pairs.Script(&d.s)
+}
+
+// A differ holds the state for a single patience diff.
+type differ struct {
+	ctx            context.Context
+	ab             myers.Pair
+	classA, classB []int
+	s              build.Builder
+}
+
+// diff diffs A[lowA:highA] against B[lowB:highB], appending the result to d.s.
+// It reports whether it completed; it returns false if d.ctx was cancelled.
+func (d *differ) diff(lowA, highA, lowB, highB int) bool {
+	if d.ctx != nil && d.ctx.Err() != nil {
+		return false
+	}
+
+	// Strip the common prefix and suffix.
+	for lowA < highA && lowB < highB && d.ab.Equal(lowA, lowB) {
+		d.s.Eq(1)
+		lowA++
+		lowB++
+	}
+	suffix := 0
+	for lowA < highA-suffix && lowB < highB-suffix && d.ab.Equal(highA-suffix-1, highB-suffix-1) {
+		suffix++
+	}
+	highA -= suffix
+	highB -= suffix
+
+	switch {
+	case lowA == highA:
+		d.s.Ins(highB - lowB)
+	case lowB == highB:
+		d.s.Del(highA - lowA)
+	default:
+		anchors := d.anchors(lowA, highA, lowB, highB)
+		if len(anchors) == 0 {
+			if !d.fallback(lowA, highA, lowB, highB) {
+				return false
+			}
+			break
+		}
+		for _, m := range anchors {
+			if !d.diff(lowA, m.a, lowB, m.b) {
+				return false
+			}
+			d.s.Eq(1)
+			lowA, lowB = m.a+1, m.b+1
+		}
+		if !d.diff(lowA, highA, lowB, highB) {
+			return false
+		}
+	}
+
+	d.s.Eq(suffix)
+	return true
+}
+
+// A match is a pair of equal elements, A[a] and B[b].
+type match struct {
+	a, b int
+}
+
+// anchors returns the longest increasing sequence of matches
+// between elements that occur exactly once in both A[lowA:highA] and B[lowB:highB].
+func (d *differ) anchors(lowA, highA, lowB, highB int) []match {
+	type count struct {
+		a, b   int // occurrences in A and B
+		ai, bi int // index of the most recent occurrence
+	}
+	counts := make(map[int]*count)
+	for ai := lowA; ai < highA; ai++ {
+		c := d.classA[ai]
+		if c < 0 {
+			continue
+		}
+		n := counts[c]
+		if n == nil {
+			n = new(count)
+			counts[c] = n
+		}
+		n.a++
+		n.ai = ai
+	}
+	for bi := lowB; bi < highB; bi++ {
+		if n := counts[d.classB[bi]]; n != nil {
+			n.b++
+			n.bi = bi
+		}
+	}
+
+	var unique []match
+	for ai := lowA; ai < highA; ai++ {
+		if n := counts[d.classA[ai]]; n != nil && n.a == 1 && n.b == 1 {
+			unique = append(unique, match{a: ai, b: n.bi})
+		}
+	}
+	return longestIncreasing(unique)
+}
+
+// longestIncreasing returns the longest subsequence of ms
+// whose b values are increasing, using patience sorting.
+// The elements of ms must be sorted by a.
+func longestIncreasing(ms []match) []match {
+	if len(ms) == 0 {
+		return nil
+	}
+	// tops[i] is the index in ms of the top card of pile i.
+	// prev[j] is the index in ms of the top card of the previous pile
+	// at the time that ms[j] was placed.
+	tops := make([]int, 0, len(ms))
+	prev := make([]int, len(ms))
+	for j, m := range ms {
+		i := sort.Search(len(tops), func(i int) bool { return ms[tops[i]].b > m.b })
+		prev[j] = -1
+		if i > 0 {
+			prev[j] = tops[i-1]
+		}
+		if i == len(tops) {
+			tops = append(tops, j)
+		} else {
+			tops[i] = j
+		}
+	}
+	out := make([]match, len(tops))
+	for i, j := len(tops)-1, tops[len(tops)-1]; i >= 0; i, j = i-1, prev[j] {
+		out[i] = ms[j]
+	}
+	return out
+}
+
+// fallback diffs A[lowA:highA] against B[lowB:highB] using myers.Diff.
+func (d *differ) fallback(lowA, highA, lowB, highB int) bool {
+	e, err := myers.DiffErr(d.ctx, pairs.Sub(d.ab, lowA, highA, lowB, highB))
+	if err != nil {
+		return false
+	}
+	for _, r := range e.Ranges {
+		pairs.Add(&d.s, r.Op(), r.Len())
+	}
+	return true
+}
@@ -0,0 +1,245 @@
+package patience_test
+
+import (
+	"bytes"
+	"context"
+	"math/rand"
+	"strings"
+	"testing"
+
+	"github.com/pkg/diff/ctxt"
+	"github.com/pkg/diff/funcname"
+	"github.com/pkg/diff/internal/difftest"
+	"github.com/pkg/diff/myers"
+	"github.com/pkg/diff/patience"
+	"github.com/pkg/diff/write"
+)
+
+var goldenTests = []struct {
+	name string
+	a, b string
+	want string // from git diff --no-index --no-prefix --patience a b
+}{
+	{
+		name: "MovedFunction",
+		a: `
+func a() {
+	one()
+}
+
+func b() {
+	two()
+}
+`[1:],
+		b: `
+func b() {
+	two()
+}
+
+func a() {
+	one()
+}
+`[1:],
+		want: `
+--- a
++++ b
+@@ -1,7 +1,7 @@
+-func a() {
+-	one()
+-}
+-
+ func b() {
+ 	two()
+ }
++
++func a() {
++	one()
++}
+`[1:],
+	},
+	{
+		name: "NewFunction",
+		a: `
+func a() {
+	one()
+}
+
+func c() {
+	three()
+}
+`[1:],
+		b: `
+func a() {
+	one()
+}
+
+func b() {
+	two()
+}
+
+func c() {
+	three()
+}
+`[1:],
+		want: `
+--- a
++++ b
+@@ -2,6 +2,10 @@ func a() {
+ 	one()
+ }
+ 
++func b() {
++	two()
++}
++
+ func c() {
+ 	three()
+ }
+`[1:],
+	},
+	{
+		name: "Slide1",
+		a:    "f\nc\nb\ne\nc\nc\nb\nb\nb\ne\nc\n",
+		b:    "f\ne\nc\nc\nb\ne\nc\n",
+		want: `
+--- a
++++ b
+@@ -1,11 +1,7 @@
+ f
+-c
+-b
+ e
+ c
+ c
+ b
+-b
+-b
+ e
+ c
+`[1:],
+	},
+	{
+		name: "Slide2",
+		a:    "e\nb\ng\nb\ne\ng\ne\nd\n",
+		b:    "e\nb\ng\ng\ne\nd\nf\nd\n",
+		want: `
+--- a
++++ b
+@@ -1,8 +1,8 @@
+ e
+ b
+ g
+-b
+-e
+ g
+ e
+ d
++f
++d
+`[1:],
+	},
+	{
+		name: "Slide3",
+		a:    "f\ng\ng\ne\ng\ng\ng\nf\nb\nf\nd\nb\n",
+		b:    "b\na\nf\ng\ng\ng\ng\nf\nb\nf\nd\nb\n",
+		want: `
+--- a
++++ b
+@@ -1,8 +1,8 @@
++b
++a
+ f
+ g
+ g
+-e
+-g
+ g
+ g
+ f
+`[1:],
+	},
+	{
+		name: "Slide4",
+		a:    "d\nb\nf\ne\na\nf\nd\nf\n",
+		b:    "f\ne\na\nf\n",
+		want: `
+--- a
++++ b
+@@ -1,8 +1,4 @@
+-d
+-b
+ f
+ e
+ a
+ f
+-d
+-f
+`[1:],
+	},
+}
+
+func TestGolden(t *testing.T) {
+	for _, test := range goldenTests {
+		t.Run(test.name, func(t *testing.T) {
+			ab := &difftest.Strings{A: lines(test.a), B: lines(test.b)}
+			e := patience.Diff(context.Background(), ab)
+			e = ctxt.Size(e, 3)
+			buf := new(bytes.Buffer)
+			// git adds section headers, using its default pattern.
+			headers := write.SectionHeaders(funcname.Headers(ab.A, funcname.Default))
+			if err := write.Unified(e, buf, ab, headers); err != nil {
+				t.Fatal(err)
+			}
+			if got := buf.String(); got != test.want {
+				t.Errorf("bad diff:\ngot:\n%s\nwant:\n%s", got, test.want)
+			}
+		})
+	}
+}
+
+// lines splits s into lines, as git does.
+func lines(s string) []string {
+	return strings.Split(strings.TrimSuffix(s, "\n"), "\n")
+}
+
+func TestPatienceRandom(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	for i := 0; i < 1000; i++ {
+		a := difftest.RandString(rng, rng.Intn(20), "ABCDEFGH")
+		b := difftest.RandString(rng, rng.Intn(20), "ABCDEFGH")
+		// Check both the keyed and unkeyed code paths.
+		for _, ab := range []myers.Pair{&difftest.Bytes{A: a, B: b}, &difftest.KeyedBytes{Bytes: difftest.Bytes{A: a, B: b}}} {
+			e := patience.Diff(context.Background(), ab)
+			if err := difftest.CheckScript(a, b, e); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+}
+
+func TestPatienceCancel(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	e := patience.Diff(ctx, &difftest.Bytes{A: "ABCABBA", B: "CBABAC"})
+	if len(e.Ranges) != 0 {
+		t.Errorf("got %v after cancellation, want empty script", e)
+	}
+}
+
+func TestPatienceUnkeyed(t *testing.T) {
+	// Without keys, Diff uses myers.Diff rather than
+	// comparing every element of A with every element of B.
+	ab := new(difftest.Counting)
+	for i := 0; i < 2000; i++ {
+		ab.A = append(ab.A, i)
+		ab.B = append(ab.B, i)
+	}
+	ab.B[1000] = -1
+	e := patience.Diff(context.Background(), ab)
+	if ins, del := e.Stat(); ins != 1 || del != 1 {
+		t.Errorf("got %d insertions and %d deletions, want 1 and 1", ins, del)
+	}
+	if max := 10 * (len(ab.A) + len(ab.B)); ab.N > max {
+		t.Errorf("Diff made %d comparisons, want at most %d", ab.N, max)
+	}
+}
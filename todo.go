@@ -1,15 +1,5 @@
 package diff
 
-// TODO: add a package for diffing gigantic files.
-// Instead of reading the entire thing into memory, we could
-// scan through the file once, storing the location of all newlines in each file.
-// Then Seek/ReadAt to read each line lazily as needed,
-// relying on the OS page cache for performance.
-// This will allow diffing giant files with low memory use,
-// albeit at a some time cost.
-// An alternative is to mmap the files,
-// although this is OS-specific and can be fiddly.
-
 // TODO: add a package providing a StringIntern type, something like:
 //
 // type StringIntern struct {
@@ -30,12 +20,6 @@ package diff
 // diffs them using Strings or Bytes or Slices (using reflect.DeepEqual) as appropriate,
 // and calls t.Errorf with a generated diff if they're not equal.
 
-// TODO: add support for hunk/section/function headers.
-// This will probably take the form of a write option
-// providing access to the necessary data,
-// and a package that helps calculate the necessary data.
-// There are several ways to do that calculation...
-
 // TODO: add copyright headers at top of all files
 
 // TODO: hook up some CI
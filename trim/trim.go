@@ -0,0 +1,87 @@
+// Package trim removes the common prefix and suffix of a pair before diffing.
+//
+// Most diffs touch only a small part of their inputs.
+// Removing the unchanged beginning and end first costs only
+// a linear number of comparisons, and leaves less work
+// for the diff algorithm.
+package trim
+
+import (
+	"context"
+
+	"github.com/pkg/diff/edit"
+	"github.com/pkg/diff/internal/build"
+	"github.com/pkg/diff/internal/pairs"
+	"github.com/pkg/diff/myers"
+)
+
+// Trimmed is a myers.Pair with its common prefix and suffix removed.
+type Trimmed struct {
+	ab     myers.Pair
+	Prefix int // length of the common prefix
+	Suffix int // length of the common suffix
+}
+
+// New finds the common prefix and suffix of ab.
+// The prefix and suffix do not overlap.
+func New(ab myers.Pair) *Trimmed {
+	aLen, bLen := ab.LenA(), ab.LenB()
+	t := &Trimmed{ab: ab}
+	for t.Prefix < aLen && t.Prefix < bLen && ab.Equal(t.Prefix, t.Prefix) {
+		t.Prefix++
+	}
+	for t.Prefix+t.Suffix < aLen && t.Prefix+t.Suffix < bLen && ab.Equal(aLen-t.Suffix-1, bLen-t.Suffix-1) {
+		t.Suffix++
+	}
+	return t
+}
+
+// Pair returns the portion of ab between its common prefix and suffix.
+// If ab has KeyA and KeyB methods, as used by the patience
+// and histogram packages, so does the returned Pair.
+func (t *Trimmed) Pair() myers.Pair {
+	return pairs.Sub(t.ab, t.Prefix, t.ab.LenA()-t.Suffix, t.Prefix, t.ab.LenB()-t.Suffix)
+}
+
+// Script converts e, an edit script for t.Pair(),
+// into an edit script for the original, untrimmed pair.
+// Replacements in e are split into deletions and insertions.
+func (t *Trimmed) Script(e edit.Script) edit.Script {
+	var s build.Builder
+	s.Eq(t.Prefix)
+	e = e.SplitReplacements()
+	for _, r := range e.Ranges {
+		// Compute positions from lengths, rather than offsetting the ranges,
+		// because insertions following a complete deletion are not
+		// always positioned at the end of the deletion.
+		pairs.Add(&s, r.Op(), r.Len())
+	}
+	s.Eq(t.Suffix)
+	return pairs.Script(&s)
+}
+
+// Diff calculates an edit script for ab using algo,
+// running algo only on the portion of ab between its
+// common prefix and suffix.
+//
+// If ctx is cancelled, Diff returns an empty edit.Script.
+func Diff(ctx context.Context, ab myers.Pair, algo func(context.Context, myers.Pair) edit.Script) edit.Script {
+	t := New(ab)
+	p := t.Pair()
+	var e edit.Script
+	if p.LenA() > 0 || p.LenB() > 0 {
+		e = algo(ctx, p)
+	}
+	if ctx != nil && ctx.Err() != nil {
+		return edit.Script{}
+	}
+	return t.Script(e)
+}
+
+// Wrap returns an algorithm that calls Diff with algo.
+// It may be used with diff.WithAlgorithm.
+func Wrap(algo func(context.Context, myers.Pair) edit.Script) func(context.Context, myers.Pair) edit.Script {
+	return func(ctx context.Context, ab myers.Pair) edit.Script {
+		return Diff(ctx, ab, algo)
+	}
+}
@@ -0,0 +1,107 @@
+package trim_test
+
+import (
+	"context"
+	"math/rand"
+	"testing"
+
+	"github.com/pkg/diff/edit"
+	"github.com/pkg/diff/histogram"
+	"github.com/pkg/diff/internal/difftest"
+	"github.com/pkg/diff/myers"
+	"github.com/pkg/diff/patience"
+	"github.com/pkg/diff/trim"
+)
+
+func TestNew(t *testing.T) {
+	tests := []struct {
+		a, b           string
+		prefix, suffix int
+	}{
+		{a: "", b: "", prefix: 0, suffix: 0},
+		{a: "ABC", b: "ABC", prefix: 3, suffix: 0},
+		{a: "ABXC", b: "ABYC", prefix: 2, suffix: 1},
+		{a: "AA", b: "AAA", prefix: 2, suffix: 0},
+		{a: "XBC", b: "YBC", prefix: 0, suffix: 2},
+	}
+	for _, test := range tests {
+		tr := trim.New(&difftest.Bytes{A: test.a, B: test.b})
+		if tr.Prefix != test.prefix || tr.Suffix != test.suffix {
+			t.Errorf("New(%q, %q) has prefix %d, suffix %d; want %d, %d", test.a, test.b, tr.Prefix, tr.Suffix, test.prefix, test.suffix)
+		}
+		p := tr.Pair()
+		if wantA := len(test.a) - test.prefix - test.suffix; p.LenA() != wantA {
+			t.Errorf("New(%q, %q).Pair().LenA() = %d, want %d", test.a, test.b, p.LenA(), wantA)
+		}
+	}
+}
+
+func TestPairKeys(t *testing.T) {
+	type keyer interface {
+		KeyA(ai int) interface{}
+		KeyB(bi int) interface{}
+	}
+	p := trim.New(&difftest.Bytes{A: "xaby", B: "xcy"}).Pair()
+	if _, ok := p.(keyer); ok {
+		t.Errorf("Pair() of an unkeyed pair has keys")
+	}
+	p = trim.New(&difftest.KeyedBytes{Bytes: difftest.Bytes{A: "xaby", B: "xcy"}}).Pair()
+	k, ok := p.(keyer)
+	if !ok {
+		t.Fatalf("Pair() of a keyed pair has no keys")
+	}
+	if got := k.KeyA(1); got != byte('b') {
+		t.Errorf("KeyA(1) = %v, want %v", got, byte('b'))
+	}
+	if got := k.KeyB(0); got != byte('c') {
+		t.Errorf("KeyB(0) = %v, want %v", got, byte('c'))
+	}
+}
+
+func TestDiff(t *testing.T) {
+	algos := map[string]func(context.Context, myers.Pair) edit.Script{
+		"myers":     myers.Diff,
+		"patience":  patience.Diff,
+		"histogram": histogram.Diff,
+		"joined": func(ctx context.Context, ab myers.Pair) edit.Script {
+			e := myers.Diff(ctx, ab)
+			return e.JoinReplacements()
+		},
+	}
+	rng := rand.New(rand.NewSource(1))
+	for i := 0; i < 1000; i++ {
+		a := difftest.RandString(rng, rng.Intn(20), "ABCD")
+		b := difftest.RandString(rng, rng.Intn(20), "ABCD")
+		// Check both the keyed and unkeyed code paths of patience and histogram.
+		for _, ab := range []myers.Pair{&difftest.Bytes{A: a, B: b}, &difftest.KeyedBytes{Bytes: difftest.Bytes{A: a, B: b}}} {
+			for name, algo := range algos {
+				e := trim.Diff(context.Background(), ab, algo)
+				if err := difftest.CheckScript(a, b, e); err != nil {
+					t.Fatalf("%s: %v", name, err)
+				}
+			}
+		}
+		// Trimming does not affect the minimality of Myers diffs.
+		want := myers.Diff(context.Background(), &difftest.Bytes{A: a, B: b})
+		got := trim.Wrap(myers.Diff)(context.Background(), &difftest.Bytes{A: a, B: b})
+		wantIns, wantDel := want.Stat()
+		gotIns, gotDel := got.Stat()
+		if gotIns+gotDel != wantIns+wantDel {
+			t.Errorf("a=%q b=%q: got %d edits, want %d", a, b, gotIns+gotDel, wantIns+wantDel)
+		}
+	}
+}
+
+func TestDiffReplace(t *testing.T) {
+	ab := &difftest.Bytes{A: "xaby", B: "xcdey"}
+	e := trim.Diff(context.Background(), ab, func(ctx context.Context, ab myers.Pair) edit.Script {
+		e := myers.Diff(ctx, ab)
+		return e.JoinReplacements()
+	})
+	if err := e.Validate(len(ab.A), len(ab.B)); err != nil {
+		t.Fatalf("%v: %v", e, err)
+	}
+	if err := difftest.CheckScript(ab.A, ab.B, e); err != nil {
+		t.Fatal(err)
+	}
+}
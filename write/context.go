@@ -0,0 +1,203 @@
+package write
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/pkg/diff/edit"
+)
+
+// contextTime is the layout of the times in the file header of a context diff.
+const contextTime = "Mon Jan _2 15:04:05 2006"
+
+// Context writes e to w using context diff format, as diff -c does:
+//
+//	*** a
+//	--- b
+//	***************
+//	*** 1,3 ****
+//	  a
+//	! b
+//	  c
+//	--- 1,3 ----
+//	  a
+//	! x
+//	  c
+//
+// Each hunk lists the lines of A, and then those of B.
+// Deleted lines are marked with "-", inserted lines with "+",
+// and lines of a change that both deletes and inserts lines with "!".
+// A side of a hunk that has no marked lines is omitted.
+//
+// ab writes the individual elements. Opts are optional write arguments.
+// Context returns the first error (if any) encountered.
+// Before writing, edit scripts usually have their context reduced,
+// such as by a call to ctxt.Size.
+func Context(e edit.Script, w io.Writer, ab Pair, opts ...Option) error {
+	// read opts
+	nameA := "a"
+	nameB := "b"
+	var timeA, timeB time.Time
+	color := false
+	var header func(ai int) string
+	for _, opt := range opts {
+		switch opt := opt.(type) {
+		case names:
+			nameA = opt.a
+			nameB = opt.b
+		case times:
+			timeA = opt.a
+			timeB = opt.b
+		case colorOpt:
+			color = true
+		case sectionOpt:
+			header = opt.header
+		case foreignOption:
+			// for another package
+		default:
+			panic(fmt.Sprintf("unrecognized WriteOpt type %T", opt))
+		}
+	}
+
+	bw := bufio.NewWriter(w)
+	nl, _ := ab.(NewlinePair)
+
+	// setColor switches the output to color c, or to no color if c is empty.
+	cur := ""
+	setColor := func(c string) {
+		if !color || c == cur {
+			return
+		}
+		if cur != "" {
+			bw.WriteString(ansiReset)
+		}
+		bw.WriteString(c)
+		cur = c
+	}
+
+	// per-file header
+	setColor(ansiBold)
+	fmt.Fprintf(bw, "*** %s\n", fileHeader(nameA, timeA, contextTime))
+	fmt.Fprintf(bw, "--- %s\n", fileHeader(nameB, timeB, contextTime))
+
+	for it := e.Hunks(); it.Next(); {
+		h := it.Hunk()
+		ar := lineRange{first: h.LowA, last: h.HighA}
+		br := lineRange{first: h.LowB, last: h.HighB}
+		segs, changesA, changesB := contextSegments(h.Ranges)
+
+		// Print hunk separator.
+		setColor("")
+		bw.WriteString("***************")
+		if section := sectionHeader(header, h.LowA); section != "" {
+			bw.WriteByte(' ')
+			bw.WriteString(section)
+		}
+		bw.WriteByte('\n')
+
+		// Print the lines of A.
+		setColor(ansiFgBlue)
+		fmt.Fprintf(bw, "*** %s ****\n", ar.lines())
+		for _, seg := range segs {
+			if !changesA || seg.mark == '+' {
+				continue
+			}
+			for m := seg.lowA; m < seg.highA; m++ {
+				// "- a[m]\n"
+				if seg.mark == ' ' {
+					setColor("")
+				} else {
+					setColor(ansiFgRed)
+				}
+				bw.WriteByte(seg.mark)
+				bw.WriteByte(' ')
+				ab.WriteATo(bw, m)
+				bw.WriteByte('\n')
+				if nl != nil && nl.NoNewlineA(m) {
+					setColor("")
+					bw.WriteString(noNewlineMarker)
+				}
+			}
+		}
+
+		// Print the lines of B.
+		setColor(ansiFgBlue)
+		fmt.Fprintf(bw, "--- %s ----\n", br.lines())
+		for _, seg := range segs {
+			if !changesB || seg.mark == '-' {
+				continue
+			}
+			for m := seg.lowB; m < seg.highB; m++ {
+				// "+ b[m]\n"
+				if seg.mark == ' ' {
+					setColor("")
+				} else {
+					setColor(ansiFgGreen)
+				}
+				bw.WriteByte(seg.mark)
+				bw.WriteByte(' ')
+				ab.WriteBTo(bw, m)
+				bw.WriteByte('\n')
+				if nl != nil && nl.NoNewlineB(m) {
+					setColor("")
+					bw.WriteString(noNewlineMarker)
+				}
+			}
+		}
+	}
+
+	// Always finish the output with no color, to prevent "leaking" the
+	// color into any output that follows a diff.
+	setColor("")
+
+	return bw.Flush()
+}
+
+// A contextSegment is a run of equal elements or a single change in a hunk of a context diff.
+type contextSegment struct {
+	mark        byte // ' ' for equal elements, or '-', '+', or '!' for a change
+	lowA, highA int
+	lowB, highB int
+}
+
+// contextSegments splits the ranges of a hunk into segments,
+// joining adjacent deletions, insertions, and replacements into a single change.
+// It reports whether any segment changes A and whether any segment changes B.
+func contextSegments(ranges []edit.Range) (segs []contextSegment, changesA, changesB bool) {
+	for i := 0; i < len(ranges); {
+		r := ranges[i]
+		if r.IsEqual() {
+			segs = append(segs, contextSegment{mark: ' ', lowA: r.LowA, highA: r.HighA, lowB: r.LowB, highB: r.HighB})
+			i++
+			continue
+		}
+		seg := contextSegment{lowA: r.LowA, highA: r.HighA, lowB: r.LowB, highB: r.HighB}
+		for ; i < len(ranges) && !ranges[i].IsEqual(); i++ {
+			// The ranges are contiguous, except that an insertion
+			// may be anchored at the start of the preceding deletion.
+			seg.highA = max(seg.highA, ranges[i].HighA)
+			seg.highB = max(seg.highB, ranges[i].HighB)
+		}
+		switch {
+		case seg.lowB == seg.highB:
+			seg.mark = '-'
+		case seg.lowA == seg.highA:
+			seg.mark = '+'
+		default:
+			seg.mark = '!'
+		}
+		changesA = changesA || seg.lowA < seg.highA
+		changesB = changesB || seg.lowB < seg.highB
+		segs = append(segs, seg)
+	}
+	return segs, changesA, changesB
+}
+
+func max(x, y int) int {
+	if x > y {
+		return x
+	}
+	return y
+}
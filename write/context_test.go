@@ -0,0 +1,136 @@
+package write_test
+
+import (
+	"bytes"
+	"context"
+	"testing"
+	"time"
+
+	"github.com/pkg/diff/ctxt"
+	"github.com/pkg/diff/edit"
+	"github.com/pkg/diff/myers"
+	"github.com/pkg/diff/write"
+)
+
+// The expected outputs of these tests were generated by diff -c --label a --label b.
+var contextTests = []struct {
+	name string
+	a, b string
+	opts []write.Option
+	want string
+}{
+	{
+		name: "TwoHunks",
+		a:    "1\n2\n3\n4\n5\n6\n7\n8\n9\n10\n11\n12\n",
+		b:    "1\nX\n3\n4\n5\n6\n7\n8\n9\n11\n12\nY\n",
+		want: `
+*** a
+--- b
+***************
+*** 1,5 ****
+  1
+! 2
+  3
+  4
+  5
+--- 1,5 ----
+  1
+! X
+  3
+  4
+  5
+***************
+*** 7,12 ****
+  7
+  8
+  9
+- 10
+  11
+  12
+--- 7,12 ----
+  7
+  8
+  9
+  11
+  12
++ Y
+`[1:],
+	},
+	{
+		name: "InsertOnly",
+		a:    "a\nb\n",
+		b:    "x\na\nb\n",
+		want: "*** a\n--- b\n***************\n*** 1,2 ****\n--- 1,3 ----\n+ x\n  a\n  b\n",
+	},
+	{
+		name: "DeleteOnly",
+		a:    "x\na\nb\n",
+		b:    "a\nb\n",
+		want: "*** a\n--- b\n***************\n*** 1,3 ****\n- x\n  a\n  b\n--- 1,2 ----\n",
+	},
+	{
+		name: "NoNewline",
+		a:    "a\nb\n",
+		b:    "a\nb",
+		want: `
+*** a
+--- b
+***************
+*** 1,2 ****
+  a
+! b
+--- 1,2 ----
+  a
+! b
+\ No newline at end of file
+`[1:],
+	},
+	{
+		name: "Empty",
+		a:    "",
+		b:    "a\nb\n",
+		want: "*** a\n--- b\n***************\n*** 0 ****\n--- 1,2 ----\n+ a\n+ b\n",
+	},
+	{
+		name: "NothingInCommon",
+		a:    "a\nb\nc\n",
+		b:    "x\ny\n",
+		want: "*** a\n--- b\n***************\n*** 1,3 ****\n! a\n! b\n! c\n--- 1,2 ----\n! x\n! y\n",
+	},
+	{
+		name: "WithTimesAndSectionHeaders",
+		a:    "a\nb\n",
+		b:    "a\nc\n",
+		opts: []write.Option{
+			write.Names("x", "y"),
+			write.Times(time.Date(2021, 3, 4, 5, 6, 7, 0, time.UTC), time.Time{}),
+			write.SectionHeaders(sectionHeader),
+		},
+		want: "*** x\tThu Mar  4 05:06:07 2021\n--- y\n*************** func f() {\n*** 1,2 ****\n  a\n! b\n--- 1,2 ----\n  a\n! c\n",
+	},
+	{
+		name: "WithTerminalColor",
+		a:    "a\nb\n",
+		b:    "a\n",
+		opts: []write.Option{write.TerminalColor()},
+		want: "\u001b[1m*** a\n--- b\n\u001b[0m***************\n\u001b[36m*** 1,2 ****\n\u001b[0m  a\n\u001b[31m- b\n\u001b[0m\u001b[36m--- 1 ----\n\u001b[0m",
+	},
+}
+
+func TestContext(t *testing.T) {
+	for _, test := range contextTests {
+		t.Run(test.name, func(t *testing.T) {
+			ab := newDiffLines(test.a, test.b)
+			e := ctxt.Size(myers.Diff(context.Background(), ab), 3)
+			for _, e := range []edit.Script{e, e.JoinReplacements()} {
+				buf := new(bytes.Buffer)
+				if err := write.Context(e, buf, ab, test.opts...); err != nil {
+					t.Fatal(err)
+				}
+				if got := buf.String(); got != test.want {
+					t.Errorf("bad diff: script=%v\ngot:\n%q\nwant:\n%q", e, got, test.want)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,158 @@
+package write
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+
+	"github.com/pkg/diff/edit"
+)
+
+// Ed writes e to w as an ed script that alters A into B, as diff -e does:
+//
+//	5a
+//	new
+//	.
+//	2,3d
+//
+// The changes are written in reverse order,
+// so that each command's line numbers refer to the original A.
+// The script does not end with a w command.
+//
+// An inserted line consisting of a single "." is written as ".."
+// and then repaired with an s command, as diff does.
+// An ed script cannot express that the last line of B lacks a trailing newline,
+// so such a line is written as though it had one.
+// As diff -e does, Ed treats the last lines of A and B as equal
+// if they differ only in whether they end with a newline.
+//
+// ab writes the individual elements. Opts are optional write arguments.
+// Ed scripts have no file headers, section headers, or colors,
+// so all options are ignored.
+// Ed returns the first error (if any) encountered.
+func Ed(e edit.Script, w io.Writer, ab Pair, opts ...Option) error {
+	checkOptions(opts)
+
+	bw := bufio.NewWriter(w)
+	var hunks []edit.Hunk
+	changes := changesOnly(e)
+	for it := changes.Hunks(); it.Next(); {
+		hunks = append(hunks, it.Hunk())
+	}
+	if n := len(hunks); n > 0 && sameLastLine(ab, hunks[n-1]) {
+		h := &hunks[n-1]
+		h.HighA--
+		h.HighB--
+		if h.LowA == h.HighA && h.LowB == h.HighB {
+			hunks = hunks[:n-1]
+		}
+	}
+	line := new(bytes.Buffer)
+	for i := len(hunks) - 1; i >= 0; i-- {
+		h := hunks[i]
+		ar := lineRange{first: h.LowA, last: h.HighA}
+
+		// Print command.
+		op := byte('c')
+		switch {
+		case h.LowA == h.HighA:
+			op = 'a'
+		case h.LowB == h.HighB:
+			op = 'd'
+		}
+		fmt.Fprintf(bw, "%s%c\n", ar.lines(), op)
+		if op == 'd' {
+			continue
+		}
+
+		// Print inserted lines.
+		insert := true
+		for m := h.LowB; m < h.HighB; m++ {
+			if !insert {
+				bw.WriteString("a\n")
+				insert = true
+			}
+			line.Reset()
+			ab.WriteBTo(line, m)
+			if line.String() == "." {
+				// A lone "." would end input mode.
+				// Write ".." instead, end input mode, and remove the extra dot.
+				bw.WriteString("..\n.\ns/.//\n")
+				insert = false
+				continue
+			}
+			bw.Write(line.Bytes())
+			bw.WriteByte('\n')
+		}
+		if insert {
+			bw.WriteString(".\n")
+		}
+	}
+	return bw.Flush()
+}
+
+// sameLastLine reports whether the last lines of A and B in h
+// are the last lines of their files, and differ only in whether they end with a newline.
+func sameLastLine(ab Pair, h edit.Hunk) bool {
+	nl, ok := ab.(NewlinePair)
+	if !ok || h.LowA == h.HighA || h.LowB == h.HighB || nl.NoNewlineA(h.HighA-1) == nl.NoNewlineB(h.HighB-1) {
+		return false
+	}
+	a, b := new(bytes.Buffer), new(bytes.Buffer)
+	ab.WriteATo(a, h.HighA-1)
+	ab.WriteBTo(b, h.HighB-1)
+	return bytes.Equal(a.Bytes(), b.Bytes())
+}
+
+// RCS writes e to w using the RCS diff format, as diff -n does:
+//
+//	d2 1
+//	a2 2
+//	new
+//	newer
+//
+// Each command is followed by a line number in A and a count of lines.
+// "d" deletes lines starting at the line number;
+// "a" appends the lines that follow it after the line number.
+// The changes are written in order, and line numbers refer to the original A.
+// If the last line of B lacks a trailing newline, it is written without one.
+//
+// ab writes the individual elements. Opts are optional write arguments.
+// RCS diffs have no file headers, section headers, or colors,
+// so all options are ignored.
+// RCS returns the first error (if any) encountered.
+func RCS(e edit.Script, w io.Writer, ab Pair, opts ...Option) error {
+	checkOptions(opts)
+
+	bw := bufio.NewWriter(w)
+	nl, _ := ab.(NewlinePair)
+	changes := changesOnly(e)
+	for it := changes.Hunks(); it.Next(); {
+		h := it.Hunk()
+		if h.LowA < h.HighA {
+			fmt.Fprintf(bw, "d%d %d\n", h.LowA+1, h.HighA-h.LowA)
+		}
+		if h.LowB < h.HighB {
+			fmt.Fprintf(bw, "a%d %d\n", h.HighA, h.HighB-h.LowB)
+		}
+		for m := h.LowB; m < h.HighB; m++ {
+			ab.WriteBTo(bw, m)
+			if nl == nil || !nl.NoNewlineB(m) {
+				bw.WriteByte('\n')
+			}
+		}
+	}
+	return bw.Flush()
+}
+
+// checkOptions panics if opts contains an unrecognized option.
+func checkOptions(opts []Option) {
+	for _, opt := range opts {
+		switch opt.(type) {
+		case names, times, colorOpt, sectionOpt, foreignOption:
+		default:
+			panic(fmt.Sprintf("unrecognized WriteOpt type %T", opt))
+		}
+	}
+}
@@ -0,0 +1,111 @@
+package write_test
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/pkg/diff/edit"
+	"github.com/pkg/diff/myers"
+	"github.com/pkg/diff/write"
+)
+
+// The expected outputs of these tests were generated by diff -e and diff -n.
+var edTests = []struct {
+	name    string
+	a, b    string
+	ed, rcs string
+}{
+	{
+		name: "Changes",
+		a:    "1\n2\n3\n4\n5\n",
+		b:    "1\nX\nY\n3\n5\nZ\n",
+		ed:   "5a\nZ\n.\n4d\n2c\nX\nY\n.\n",
+		rcs:  "d2 1\na2 2\nX\nY\nd4 1\na5 1\nZ\n",
+	},
+	{
+		name: "Dots",
+		a:    "a\nb\n",
+		b:    "a\n.\nb\n..\n",
+		ed:   "2a\n..\n.\n1a\n..\n.\ns/.//\n",
+		rcs:  "a1 1\n.\na2 1\n..\n",
+	},
+	{
+		name: "DotsInChange",
+		a:    "a\nb\n",
+		b:    ".\nx\n.\n",
+		ed:   "1,2c\n..\n.\ns/.//\na\nx\n..\n.\ns/.//\n",
+		rcs:  "d1 2\na2 3\n.\nx\n.\n",
+	},
+	{
+		name: "Insert",
+		a:    "",
+		b:    "a\nb\n",
+		ed:   "0a\na\nb\n.\n",
+		rcs:  "a0 2\na\nb\n",
+	},
+	{
+		name: "Delete",
+		a:    "a\nb\n",
+		b:    "",
+		ed:   "1,2d\n",
+		rcs:  "d1 2\n",
+	},
+	{
+		name: "NoNewline",
+		a:    "a\nb\n",
+		b:    "a\nb",
+		ed:   "",
+		rcs:  "d2 1\na2 1\nb",
+	},
+	{
+		name: "NoNewlineA",
+		a:    "1\n2\n3\n4\n5\nb\na\nc",
+		b:    "1\n2\n3\n4\n5\nd\nc\n",
+		ed:   "6,7c\nd\n.\n",
+		rcs:  "d6 3\na8 2\nd\nc\n",
+	},
+	{
+		name: "NoNewlineChange",
+		a:    "a\nb\n",
+		b:    "a\nc",
+		ed:   "2c\nc\n.\n",
+		rcs:  "d2 1\na2 1\nc",
+	},
+}
+
+func TestEd(t *testing.T) {
+	for _, test := range edTests {
+		t.Run(test.name, func(t *testing.T) {
+			ab := newDiffLines(test.a, test.b)
+			e := myers.Diff(context.Background(), ab)
+			for _, e := range []edit.Script{e, e.JoinReplacements()} {
+				buf := new(bytes.Buffer)
+				if err := write.Ed(e, buf, ab, write.Names("a", "b")); err != nil {
+					t.Fatal(err)
+				}
+				if got := buf.String(); got != test.ed {
+					t.Errorf("bad ed script: script=%v\ngot:\n%q\nwant:\n%q", e, got, test.ed)
+				}
+			}
+		})
+	}
+}
+
+func TestRCS(t *testing.T) {
+	for _, test := range edTests {
+		t.Run(test.name, func(t *testing.T) {
+			ab := newDiffLines(test.a, test.b)
+			e := myers.Diff(context.Background(), ab)
+			for _, e := range []edit.Script{e, e.JoinReplacements()} {
+				buf := new(bytes.Buffer)
+				if err := write.RCS(e, buf, ab, write.Names("a", "b")); err != nil {
+					t.Fatal(err)
+				}
+				if got := buf.String(); got != test.rcs {
+					t.Errorf("bad RCS diff: script=%v\ngot:\n%q\nwant:\n%q", e, got, test.rcs)
+				}
+			}
+		})
+	}
+}
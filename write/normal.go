@@ -0,0 +1,136 @@
+package write
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strconv"
+
+	"github.com/pkg/diff/edit"
+)
+
+// Normal writes e to w using normal diff format,
+// the default output format of diff, as in:
+//
+//	3c3,4
+//	< old
+//	---
+//	> new
+//	> newer
+//
+// ab writes the individual elements. Opts are optional write arguments.
+// Normal returns the first error (if any) encountered.
+//
+// Normal diffs have no context, so equal elements in e are ignored,
+// and adjacent deletions and insertions are written as a single change.
+// Normal diffs also have no file headers or section headers,
+// so Names, Times, and SectionHeaders options are ignored.
+func Normal(e edit.Script, w io.Writer, ab Pair, opts ...Option) error {
+	// read opts
+	color := false
+	for _, opt := range opts {
+		switch opt.(type) {
+		case names, times, sectionOpt:
+			// not applicable
+		case colorOpt:
+			color = true
+		case foreignOption:
+			// for another package
+		default:
+			panic(fmt.Sprintf("unrecognized WriteOpt type %T", opt))
+		}
+	}
+
+	bw := bufio.NewWriter(w)
+	nl, _ := ab.(NewlinePair)
+
+	// setColor switches the output to color c, or to no color if c is empty.
+	cur := ""
+	setColor := func(c string) {
+		if !color || c == cur {
+			return
+		}
+		if cur != "" {
+			bw.WriteString(ansiReset)
+		}
+		bw.WriteString(c)
+		cur = c
+	}
+
+	changes := changesOnly(e)
+	for it := changes.Hunks(); it.Next(); {
+		h := it.Hunk()
+		ar := lineRange{first: h.LowA, last: h.HighA}
+		br := lineRange{first: h.LowB, last: h.HighB}
+
+		// Print change command.
+		op := byte('c')
+		switch {
+		case h.LowA == h.HighA:
+			op = 'a'
+		case h.LowB == h.HighB:
+			op = 'd'
+		}
+		setColor(ansiFgBlue)
+		fmt.Fprintf(bw, "%s%c%s\n", ar.lines(), op, br.lines())
+
+		// Print prefixed lines.
+		for m := h.LowA; m < h.HighA; m++ {
+			// "< a[m]\n"
+			setColor(ansiFgRed)
+			bw.WriteString("< ")
+			ab.WriteATo(bw, m)
+			bw.WriteByte('\n')
+			if nl != nil && nl.NoNewlineA(m) {
+				setColor("")
+				bw.WriteString(noNewlineMarker)
+			}
+		}
+		if op == 'c' {
+			setColor("")
+			bw.WriteString("---\n")
+		}
+		for m := h.LowB; m < h.HighB; m++ {
+			// "> b[m]\n"
+			setColor(ansiFgGreen)
+			bw.WriteString("> ")
+			ab.WriteBTo(bw, m)
+			bw.WriteByte('\n')
+			if nl != nil && nl.NoNewlineB(m) {
+				setColor("")
+				bw.WriteString(noNewlineMarker)
+			}
+		}
+	}
+
+	// Always finish the output with no color, to prevent "leaking" the
+	// color into any output that follows a diff.
+	setColor("")
+
+	return bw.Flush()
+}
+
+// changesOnly returns e without its ranges of equal elements,
+// so that each hunk of the result is a single change.
+func changesOnly(e edit.Script) edit.Script {
+	var changes edit.Script
+	for _, r := range e.Ranges {
+		if !r.IsEqual() {
+			changes.Ranges = append(changes.Ranges, r)
+		}
+	}
+	return changes
+}
+
+// lines formats r as in normal diffs: "first,last" using 1-based line numbers,
+// just "first" if r has a single line,
+// or, if r is empty, the number of the line before it.
+func (r lineRange) lines() string {
+	switch r.last - r.first {
+	case 0:
+		return strconv.Itoa(r.first)
+	case 1:
+		return strconv.Itoa(r.last)
+	}
+	return fmt.Sprintf("%d,%d", r.first+1, r.last)
+}
@@ -0,0 +1,111 @@
+package write_test
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/pkg/diff/ctxt"
+	"github.com/pkg/diff/edit"
+	"github.com/pkg/diff/myers"
+	"github.com/pkg/diff/write"
+)
+
+// The expected outputs of these tests were generated by running diff without flags.
+var normalTests = []struct {
+	name string
+	a, b string
+	opts []write.Option
+	want string
+}{
+	{
+		name: "Change",
+		a:    "1\n2\n3\n4\n",
+		b:    "1\nX\nY\n3\n4\n5",
+		want: `
+2c2,3
+< 2
+---
+> X
+> Y
+4a6
+> 5
+\ No newline at end of file
+`[1:],
+	},
+	{
+		name: "DeleteStart",
+		a:    "a\nb\nc\n",
+		b:    "b\nc\n",
+		want: "1d0\n< a\n",
+	},
+	{
+		name: "InsertStart",
+		a:    "a\nb\nc\n",
+		b:    "x\na\nb\nc\n",
+		want: "0a1\n> x\n",
+	},
+	{
+		name: "DeleteMany",
+		a:    "a\nb\nc\nd\n",
+		b:    "a\nd\n",
+		want: "2,3d1\n< b\n< c\n",
+	},
+	{
+		name: "NoNewline",
+		a:    "a\nb",
+		b:    "a\nc",
+		want: `
+2c2
+< b
+\ No newline at end of file
+---
+> c
+\ No newline at end of file
+`[1:],
+	},
+	{
+		name: "NothingInCommon",
+		a:    "a\nb\n",
+		b:    "c\n",
+		want: "1,2c1\n< a\n< b\n---\n> c\n",
+	},
+	{
+		name: "Empty",
+		a:    "",
+		b:    "a\n",
+		want: "0a1\n> a\n",
+	},
+	{
+		name: "Equal",
+		a:    "a\n",
+		b:    "a\n",
+		want: "",
+	},
+	{
+		name: "WithTerminalColor",
+		a:    "1\n2\n",
+		b:    "1\n3\n4\n",
+		opts: []write.Option{write.Names("x", "y"), write.TerminalColor()},
+		want: "\u001b[36m2c2,3\n\u001b[0m\u001b[31m< 2\n\u001b[0m---\n\u001b[32m> 3\n> 4\n\u001b[0m",
+	},
+}
+
+func TestNormal(t *testing.T) {
+	for _, test := range normalTests {
+		t.Run(test.name, func(t *testing.T) {
+			ab := newDiffLines(test.a, test.b)
+			e := myers.Diff(context.Background(), ab)
+			// Replacements and reduced context do not change the output.
+			for _, e := range []edit.Script{e, e.JoinReplacements(), ctxt.Size(e, 0)} {
+				buf := new(bytes.Buffer)
+				if err := write.Normal(e, buf, ab, test.opts...); err != nil {
+					t.Fatal(err)
+				}
+				if got := buf.String(); got != test.want {
+					t.Errorf("bad diff: script=%v\ngot:\n%s\nwant:\n%s", e, got, test.want)
+				}
+			}
+		})
+	}
+}
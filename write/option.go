@@ -1,6 +1,8 @@
 // Package write provides routines for writing diffs.
 package write
 
+import "time"
+
 // An Option modifies behavior when writing a diff.
 type Option interface {
 	isOption()
@@ -18,6 +20,61 @@ type names struct {
 
 func (names) isOption() {}
 
+// ForeignOption may be embedded in an option type defined by another package,
+// such as package diff, to make it an Option. This lets such options be mixed
+// with this package's options and passed along with them.
+// The functions in this package ignore them.
+type ForeignOption struct{}
+
+func (ForeignOption) isOption()        {}
+func (ForeignOption) isForeignOption() {}
+
+// A foreignOption is an Option that embeds ForeignOption.
+type foreignOption interface {
+	isForeignOption()
+}
+
+// Times provides the modification times of A and B for writing a diff.
+// They are written after the names in the file header,
+// in the format that diff uses for each output format.
+// A zero time is not written.
+func Times(a, b time.Time) Option {
+	return times{a, b}
+}
+
+type times struct {
+	a, b time.Time
+}
+
+func (times) isOption() {}
+
+// fileHeader returns name, followed by t in the given layout if t is not zero.
+func fileHeader(name string, t time.Time, layout string) string {
+	if t.IsZero() {
+		return name
+	}
+	return name + "\t" + t.Format(layout)
+}
+
+// SectionHeaders specifies a function that provides the section header
+// written after the line numbers of each hunk, as in
+//
+//	@@ -10,7 +10,8 @@ func Foo() {
+//
+// header is called with the index in A of the first element of the hunk,
+// including context. It returns the header, or the empty string if the hunk has none.
+// The header must not contain a newline.
+// Package funcname can calculate headers as git does.
+func SectionHeaders(header func(ai int) string) Option {
+	return sectionOpt{header: header}
+}
+
+type sectionOpt struct {
+	header func(ai int) string
+}
+
+func (sectionOpt) isOption() {}
+
 // TerminalColor specifies that a diff intended
 // for a terminal should be written using colors.
 //
@@ -4,6 +4,7 @@ import (
 	"bufio"
 	"fmt"
 	"io"
+	"time"
 
 	"github.com/pkg/diff/edit"
 )
@@ -17,6 +18,28 @@ type Pair interface {
 	WriteBTo(w io.Writer, bi int) (int, error)
 }
 
+// A NewlinePair is a Pair whose elements are lines,
+// some of which may lack a trailing newline.
+// Typically only the last line of a file lacks one.
+//
+// When writing the elements of a NewlinePair,
+// Unified follows each line that lacks a trailing newline
+// with the line "\ No newline at end of file",
+// as diff and git do, so that the diff can be applied with patch or git apply.
+type NewlinePair interface {
+	Pair
+	// NoNewlineA reports whether a[aᵢ] lacks a trailing newline.
+	NoNewlineA(ai int) bool
+	// NoNewlineB reports whether b[bᵢ] lacks a trailing newline.
+	NoNewlineB(bi int) bool
+}
+
+// unifiedTime is the layout of the times in the file header of a unified diff.
+const unifiedTime = "2006-01-02 15:04:05.000000000 -0700"
+
+// noNewlineMarker follows a line that lacks a trailing newline.
+const noNewlineMarker = "\\ No newline at end of file\n"
+
 // Unified writes e to w using unified diff format.
 // ab writes the individual elements. Opts are optional write arguments.
 // Unified returns the number of bytes written and the first error (if any) encountered.
@@ -26,69 +49,55 @@ func Unified(e edit.Script, w io.Writer, ab Pair, opts ...Option) error {
 	// read opts
 	nameA := "a"
 	nameB := "b"
+	var timeA, timeB time.Time
 	color := false
+	var header func(ai int) string
 	for _, opt := range opts {
 		switch opt := opt.(type) {
 		case names:
 			nameA = opt.a
 			nameB = opt.b
+		case times:
+			timeA = opt.a
+			timeB = opt.b
 		case colorOpt:
 			color = true
-		// TODO: add date/time/timezone WriteOpts
+		case sectionOpt:
+			header = opt.header
+		case foreignOption:
+			// for another package
 		default:
 			panic(fmt.Sprintf("unrecognized WriteOpt type %T", opt))
 		}
 	}
 
 	bw := bufio.NewWriter(w)
+	nl, _ := ab.(NewlinePair)
 
 	needsColorReset := false
+	// noNewline writes noNewlineMarker, uncolored.
+	noNewline := func() {
+		if needsColorReset {
+			bw.WriteString(ansiReset)
+			needsColorReset = false
+		}
+		bw.WriteString(noNewlineMarker)
+	}
 
 	// per-file header
 	if color {
 		bw.WriteString(ansiBold)
 		needsColorReset = true
 	}
-	fmt.Fprintf(bw, "--- %s\n", nameA)
-	fmt.Fprintf(bw, "+++ %s\n", nameB)
-
-	for i := 0; i < len(e.Ranges); {
-		// Peek into the future to learn the line ranges for this chunk of output.
-		// A chunk of output ends when there's a discontiguity in the edit script.
-		var ar, br lineRange
-		var started [2]bool
-		var j int
-		for j = i; j < len(e.Ranges); j++ {
-			curr := e.Ranges[j]
-			if !curr.IsInsert() {
-				if !started[0] {
-					ar.first = curr.LowA
-					started[0] = true
-				}
-				ar.last = curr.HighA
-			}
-			if !curr.IsDelete() {
-				if !started[1] {
-					br.first = curr.LowB
-					started[1] = true
-				}
-				br.last = curr.HighB
-			}
-			if j+1 >= len(e.Ranges) {
-				// end of script
-				break
-			}
-			if next := e.Ranges[j+1]; curr.HighA != next.LowA || curr.HighB != next.LowB {
-				// discontiguous edit script
-				break
-			}
-		}
+	fmt.Fprintf(bw, "--- %s\n", fileHeader(nameA, timeA, unifiedTime))
+	fmt.Fprintf(bw, "+++ %s\n", fileHeader(nameB, timeB, unifiedTime))
+
+	for it := e.Hunks(); it.Next(); {
+		h := it.Hunk()
+		ar := lineRange{first: h.LowA, last: h.HighA}
+		br := lineRange{first: h.LowB, last: h.HighB}
 
 		// Print chunk header.
-		// TODO: add per-chunk context, like what function we're in
-		// But how do we get this? need to add PairWriter methods?
-		// Maybe it should be stored in the EditScript,
-		// and we can have EditScript methods to populate it somehow?
 		if color {
 			if needsColorReset {
 				bw.WriteString(ansiReset)
@@ -96,13 +105,22 @@ func Unified(e edit.Script, w io.Writer, ab Pair, opts ...Option) error {
 			bw.WriteString(ansiFgBlue)
 			needsColorReset = true
 		}
-		fmt.Fprintf(bw, "@@ -%s +%s @@\n", ar, br)
+		fmt.Fprintf(bw, "@@ -%s +%s @@", ar, br)
+		if section := sectionHeader(header, h.LowA); section != "" {
+			// As in git, the section header is not colored.
+			if color {
+				bw.WriteString(ansiReset)
+				needsColorReset = false
+			}
+			bw.WriteByte(' ')
+			bw.WriteString(section)
+		}
+		bw.WriteByte('\n')
 
 		// Print prefixed lines.
-		for k := i; k <= j; k++ {
-			seg := e.Ranges[k]
-			switch seg.Op() {
-			case edit.Eq:
+		// A replacement is printed as a deletion followed by an insertion.
+		for _, seg := range h.Ranges {
+			if seg.IsEqual() {
 				if needsColorReset {
 					bw.WriteString(ansiReset)
 				}
@@ -111,8 +129,13 @@ func Unified(e edit.Script, w io.Writer, ab Pair, opts ...Option) error {
 					bw.WriteByte(' ')
 					ab.WriteATo(bw, m)
 					bw.WriteByte('\n')
+					if nl != nil && nl.NoNewlineA(m) {
+						noNewline()
+					}
 				}
-			case edit.Del:
+				continue
+			}
+			if !seg.IsInsert() {
 				if color {
 					bw.WriteString(ansiFgRed)
 					needsColorReset = true
@@ -122,8 +145,12 @@ func Unified(e edit.Script, w io.Writer, ab Pair, opts ...Option) error {
 					bw.WriteByte('-')
 					ab.WriteATo(bw, m)
 					bw.WriteByte('\n')
+					if nl != nil && nl.NoNewlineA(m) {
+						noNewline()
+					}
 				}
-			case edit.Ins:
+			}
+			if !seg.IsDelete() {
 				if color {
 					bw.WriteString(ansiFgGreen)
 					needsColorReset = true
@@ -133,13 +160,13 @@ func Unified(e edit.Script, w io.Writer, ab Pair, opts ...Option) error {
 					bw.WriteByte('+')
 					ab.WriteBTo(bw, m)
 					bw.WriteByte('\n')
+					if nl != nil && nl.NoNewlineB(m) {
+						noNewline()
+					}
 				}
 			}
 		}
 
-		// Advance to next chunk.
-		i = j + 1
-
 		// TODO: break if error detected?
 	}
 
@@ -149,15 +176,18 @@ func Unified(e edit.Script, w io.Writer, ab Pair, opts ...Option) error {
 		bw.WriteString(ansiReset)
 	}
 
-	// TODO:
-	// If the last line of a file doesn't end in a newline character,
-	// it is displayed with a newline character,
-	// and the following line in the chunk has the literal text (starting in the first column):
-	// '\ No newline at end of file'
-
 	return bw.Flush()
 }
 
+// sectionHeader returns the section header for a hunk starting at a[ai],
+// or the empty string if header is nil.
+func sectionHeader(header func(ai int) string, ai int) string {
+	if header == nil {
+		return ""
+	}
+	return header(ai)
+}
+
 type lineRange struct {
 	first, last int
 }
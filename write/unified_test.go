@@ -6,8 +6,10 @@ import (
 	"io"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/pkg/diff/ctxt"
+	"github.com/pkg/diff/edit"
 	"github.com/pkg/diff/myers"
 	"github.com/pkg/diff/write"
 )
@@ -71,6 +73,64 @@ var goldenTests = []struct {
 +3
 ` + "\u001b[0m",
 	},
+
+	{
+		name: "WithTimes",
+		a:    "a\nb\n",
+		b:    "a\nc\n",
+		opts: []write.Option{write.Times(
+			time.Date(2021, 3, 4, 5, 6, 7, 89, time.FixedZone("", -7*60*60)),
+			time.Date(2021, 3, 4, 6, 0, 0, 0, time.UTC),
+		)},
+		want: `
+--- a	2021-03-04 05:06:07.000000089 -0700
++++ b	2021-03-04 06:00:00.000000000 +0000
+@@ -1,3 +1,3 @@
+ a
+-b
++c
+ 
+`[1:],
+	},
+
+	{
+		name: "WithSectionHeaders",
+		a:    "func f() {\n1\n2\n3\n4\n5\n}\n",
+		b:    "func f() {\n1\n2\n3\n4\nX\n}\n",
+		opts: []write.Option{write.SectionHeaders(sectionHeader)},
+		want: `
+--- a
++++ b
+@@ -3,6 +3,6 @@ func f() {
+ 2
+ 3
+ 4
+-5
++X
+ }
+ 
+`[1:],
+	},
+
+	{
+		name: "WithSectionHeadersAndTerminalColor",
+		a:    "1\n2",
+		b:    "1\n3",
+		opts: []write.Option{write.SectionHeaders(sectionHeader), write.TerminalColor()},
+		want: `
+`[1:] + "\u001b[1m" + `--- a
++++ b
+` + "\u001b[0m" + "\u001b[36m" + `@@ -1,2 +1,2 @@` + "\u001b[0m" + ` func f() {
+ 1
+` + "\u001b[31m" + `-2
+` + "\u001b[32m" + `+3
+` + "\u001b[0m",
+	},
+}
+
+// sectionHeader pretends that every hunk is in the function f.
+func sectionHeader(ai int) string {
+	return "func f() {"
 }
 
 func TestGolden(t *testing.T) {
@@ -84,19 +144,22 @@ func TestGolden(t *testing.T) {
 			// vs unified diff formatting.
 			e := myers.Diff(context.Background(), ab)
 			e = ctxt.Size(e, 3)
-			buf := new(bytes.Buffer)
-			err := write.Unified(e, buf, ab, test.opts...)
-			if err != nil {
-				t.Fatal(err)
-			}
-			got := buf.String()
-			if test.want != got {
-				t.Logf("%q\n", test.want)
-				t.Logf("%q\n", got)
-				t.Errorf("bad diff: a=%q b=%q\n\ngot:\n%s\nwant:\n%s",
-					test.a, test.b,
-					got, test.want,
-				)
+			// Replacements are written as deletions followed by insertions.
+			for _, e := range []edit.Script{e, e.JoinReplacements()} {
+				buf := new(bytes.Buffer)
+				err := write.Unified(e, buf, ab, test.opts...)
+				if err != nil {
+					t.Fatal(err)
+				}
+				got := buf.String()
+				if test.want != got {
+					t.Logf("%q\n", test.want)
+					t.Logf("%q\n", got)
+					t.Errorf("bad diff: a=%q b=%q script=%v\n\ngot:\n%s\nwant:\n%s",
+						test.a, test.b, e,
+						got, test.want,
+					)
+				}
 			}
 		})
 	}
@@ -111,3 +174,142 @@ func (ab *diffStrings) LenB() int                                { return len(ab
 func (ab *diffStrings) Equal(ai, bi int) bool                    { return ab.a[ai] == ab.b[bi] }
 func (ab *diffStrings) WriteATo(w io.Writer, i int) (int, error) { return io.WriteString(w, ab.a[i]) }
 func (ab *diffStrings) WriteBTo(w io.Writer, i int) (int, error) { return io.WriteString(w, ab.b[i]) }
+
+func TestHunkHeaders(t *testing.T) {
+	tests := []struct {
+		name string
+		a, b []string
+		n    int
+		want string
+	}{
+		{
+			name: "InsertNoContext",
+			a:    []string{"1", "2", "3"},
+			b:    []string{"1", "2", "X", "3"},
+			n:    0,
+			want: "--- a\n+++ b\n@@ -2,0 +3,1 @@\n+X\n",
+		},
+		{
+			name: "DeleteNoContext",
+			a:    []string{"1", "X", "2"},
+			b:    []string{"1", "2"},
+			n:    0,
+			want: "--- a\n+++ b\n@@ -2,1 +1,0 @@\n-X\n",
+		},
+		{
+			name: "NothingInCommon",
+			a:    []string{"a"},
+			b:    []string{"b"},
+			n:    3,
+			want: "--- a\n+++ b\n@@ -1,1 +1,1 @@\n-a\n+b\n",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ab := &diffStrings{a: test.a, b: test.b}
+			e := ctxt.Size(myers.Diff(context.Background(), ab), test.n)
+			buf := new(bytes.Buffer)
+			if err := write.Unified(e, buf, ab); err != nil {
+				t.Fatal(err)
+			}
+			if got := buf.String(); got != test.want {
+				t.Errorf("bad diff:\ngot:\n%s\nwant:\n%s", got, test.want)
+			}
+		})
+	}
+}
+
+func TestNoNewline(t *testing.T) {
+	ab := &diffLines{
+		diffStrings: diffStrings{a: []string{"a", "x", "c"}, b: []string{"a", "b", "c"}},
+		noNewlineA:  true,
+		noNewlineB:  false,
+	}
+	e := myers.Diff(context.Background(), ab)
+	e = ctxt.Size(e, 3)
+	buf := new(bytes.Buffer)
+	if err := write.Unified(e, buf, ab); err != nil {
+		t.Fatal(err)
+	}
+	want := `
+--- a
++++ b
+@@ -1,3 +1,3 @@
+ a
+-x
+-c
+\ No newline at end of file
++b
++c
+`[1:]
+	if got := buf.String(); got != want {
+		t.Errorf("bad diff:\ngot:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+// diffLines is a diffStrings whose last lines may lack a trailing newline.
+type diffLines struct {
+	diffStrings
+	noNewlineA, noNewlineB bool
+}
+
+func (ab *diffLines) NoNewlineA(ai int) bool { return ab.noNewlineA && ai == len(ab.a)-1 }
+func (ab *diffLines) NoNewlineB(bi int) bool { return ab.noNewlineB && bi == len(ab.b)-1 }
+
+func (ab *diffLines) Equal(ai, bi int) bool {
+	return ab.a[ai] == ab.b[bi] && ab.NoNewlineA(ai) == ab.NoNewlineB(bi)
+}
+
+// newDiffLines returns a diffLines for the texts a and b,
+// split into lines as diff does.
+func newDiffLines(a, b string) *diffLines {
+	ab := new(diffLines)
+	ab.a, ab.noNewlineA = splitLines(a)
+	ab.b, ab.noNewlineB = splitLines(b)
+	return ab
+}
+
+// splitLines splits s into lines, without their trailing newlines,
+// and reports whether the last line lacks one.
+func splitLines(s string) (lines []string, noNewline bool) {
+	if s == "" {
+		return nil, false
+	}
+	noNewline = !strings.HasSuffix(s, "\n")
+	return strings.Split(strings.TrimSuffix(s, "\n"), "\n"), noNewline
+}
+
+// foreignOpt is an option for another package.
+type foreignOpt struct {
+	write.ForeignOption
+}
+
+func TestForeignOption(t *testing.T) {
+	writers := []struct {
+		name  string
+		write func(edit.Script, io.Writer, write.Pair, ...write.Option) error
+	}{
+		{"Unified", write.Unified},
+		{"Context", write.Context},
+		{"Normal", write.Normal},
+		{"Ed", write.Ed},
+		{"RCS", write.RCS},
+	}
+	ab := &diffStrings{a: []string{"a", "b", "c"}, b: []string{"a", "x", "c"}}
+	e := myers.Diff(context.Background(), ab)
+	for _, w := range writers {
+		t.Run(w.name, func(t *testing.T) {
+			want := new(bytes.Buffer)
+			if err := w.write(e, want, ab, write.Names("a", "b")); err != nil {
+				t.Fatal(err)
+			}
+			got := new(bytes.Buffer)
+			if err := w.write(e, got, ab, write.Names("a", "b"), foreignOpt{}); err != nil {
+				t.Fatal(err)
+			}
+			if got.String() != want.String() {
+				t.Errorf("got:\n%s\nwant:\n%s", got, want)
+			}
+		})
+	}
+}